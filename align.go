@@ -0,0 +1,203 @@
+package tran
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AlignMode is how an Aligner pairs the lines of a text with their
+// translations.
+type AlignMode int
+
+const (
+	// AlignAuto translates the lines with markers, and each line apart
+	// if the markers are lost.
+	AlignAuto AlignMode = iota
+
+	// AlignMarkers translates the lines at once with markers such as
+	// "⟦0⟧" before them, and splits the translation at the markers.
+	AlignMarkers
+
+	// AlignLines translates each line apart.
+	AlignLines
+)
+
+var alignModes = []string{"auto", "markers", "lines"}
+
+func (m AlignMode) String() string {
+	if m < 0 || int(m) >= len(alignModes) {
+		return "AlignMode(" + strconv.Itoa(int(m)) + ")"
+	}
+	return alignModes[m]
+}
+
+// ParseAlignMode returns the AlignMode of the name "auto", "markers" or
+// "lines".
+func ParseAlignMode(s string) (AlignMode, error) {
+	for i, name := range alignModes {
+		if s == name {
+			return AlignMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid align mode: %q, want: %s", s, strings.Join(alignModes, ", "))
+}
+
+// AlignmentError is returned by an Aligner when the translation of the
+// lines of a text cannot be paired with them.
+type AlignmentError struct {
+	Lines  int // the lines to translate
+	Marked int // the lines found by their markers in the translation
+}
+
+func (e *AlignmentError) Error() string {
+	return fmt.Sprintf("translation is not aligned with the source: %d of %d lines are found",
+		e.Marked, e.Lines)
+}
+
+// Aligner is a Translator whose translation of a text has the same
+// lines as the text, each of which is the translation of the same line
+// of the text, so that they are paired reliably. The blank lines are
+// kept as they are, and the lines of a translation of a line are joined
+// with spaces. A Cache or a Memory should wrap an Aligner rather than be
+// wrapped by it, not to store the lines with markers. The texts
+// translated with a context of WithoutAlignment are not aligned.
+type Aligner struct {
+	Translator
+	Mode AlignMode
+}
+
+// NewAligner returns an Aligner of tr which pairs the lines by mode.
+func NewAligner(tr Translator, mode AlignMode) *Aligner {
+	return &Aligner{Translator: tr, Mode: mode}
+}
+
+type withoutAlignmentKey struct{}
+
+// WithoutAlignment returns a context of ctx with which the Aligners
+// translate the texts by the underlying Translators as they are, such as
+// the segments of documents, which have no lines to pair.
+func WithoutAlignment(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutAlignmentKey{}, true)
+}
+
+func (a *Aligner) Translate(text, source, target string) (string, error) {
+	return a.TranslateContext(context.Background(), text, source, target)
+}
+
+func (a *Aligner) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	res, err := a.TranslateResult(ctx, text, source, target)
+	if err != nil {
+		return "", err
+	}
+	return res.Text, nil
+}
+
+func (a *Aligner) TranslateResult(ctx context.Context, text, source, target string) (*Result, error) {
+	if ctx.Value(withoutAlignmentKey{}) != nil {
+		return TranslateResult(ctx, a.Translator, text, source, target)
+	}
+	lines := strings.Split(text, "\n")
+	var segs []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			segs = append(segs, line)
+		}
+	}
+	if len(segs) == 0 {
+		return &Result{Text: text, Source: source}, nil
+	}
+	outs, res, err := a.translate(ctx, segs, source, target)
+	if err != nil {
+		return nil, err
+	}
+	i := 0
+	for j, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[j] = strings.Join(strings.Fields(outs[i]), " ")
+			i++
+		}
+	}
+	return &Result{Text: strings.Join(lines, "\n"), Source: res.Source, Cached: res.Cached, Pseudo: res.Pseudo}, nil
+}
+
+// translate returns the translations of segs in the same order, and the
+// Result of the source language detected for the first one, which is
+// cached if all of them are, and pseudo if any of them is.
+func (a *Aligner) translate(ctx context.Context, segs []string, source, target string) ([]string, *Result, error) {
+	if a.Mode != AlignLines && len(segs) > 1 {
+		outs, res, err := a.marked(ctx, segs, source, target)
+		var pe *PlaceholderError
+		var ae *AlignmentError
+		switch {
+		case err == nil:
+			return outs, res, nil
+		case a.Mode == AlignAuto && (errors.As(err, &pe) || errors.As(err, &ae)):
+			// The lines are translated apart.
+		default:
+			return nil, nil, err
+		}
+	}
+	outs := make([]string, len(segs))
+	all := &Result{Source: source, Cached: true}
+	for i, s := range segs {
+		res, err := TranslateResult(ctx, a.Translator, s, source, target)
+		if err != nil {
+			return nil, nil, err
+		}
+		outs[i] = res.Text
+		if i == 0 {
+			all.Source = res.Source
+		}
+		all.Cached = all.Cached && res.Cached
+		all.Pseudo = all.Pseudo || res.Pseudo
+	}
+	return outs, all, nil
+}
+
+// marked translates segs at once with markers before them, and splits
+// the translation at the markers.
+func (a *Aligner) marked(ctx context.Context, segs []string, source, target string) ([]string, *Result, error) {
+	var sb strings.Builder
+	for i, s := range segs {
+		if maskPattern.MatchString(s) {
+			// The markers cannot be told from the masks in the text.
+			return nil, nil, &AlignmentError{Lines: len(segs)}
+		}
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "⟦%d⟧ %s", i, strings.TrimSpace(s))
+	}
+	res, err := TranslateResult(ctx, a.Translator, sb.String(), source, target)
+	if err != nil {
+		return nil, nil, err
+	}
+	outs, ok := splitMarked(res.Text, len(segs))
+	if !ok {
+		return nil, nil, &AlignmentError{Lines: len(segs), Marked: len(outs)}
+	}
+	return outs, res, nil
+}
+
+// splitMarked splits s at the markers of n lines, which must be in
+// order. It returns the lines found if they are not.
+func splitMarked(s string, n int) (outs []string, ok bool) {
+	ms := maskPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(ms) == 0 || strings.TrimSpace(s[:ms[0][0]]) != "" {
+		return nil, false
+	}
+	for i, m := range ms {
+		if k, err := strconv.Atoi(s[m[2]:m[3]]); err != nil || k != i {
+			return outs, false
+		}
+		end := len(s)
+		if i+1 < len(ms) {
+			end = ms[i+1][0]
+		}
+		outs = append(outs, strings.TrimSpace(s[m[1]:end]))
+	}
+	return outs, len(outs) == n
+}
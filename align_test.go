@@ -0,0 +1,104 @@
+package tran
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type AlignerTest struct {
+	in    string
+	mode  AlignMode
+	dict  dictTranslator
+	out   string
+	calls int // of the translations, or -1 for an AlignmentError
+}
+
+var alignertests = []AlignerTest{
+	0: {"", AlignAuto, dictTranslator{}, "", 0},
+	1: {"Hello", AlignAuto, dictTranslator{"Hello": "Bonjour\nà tous"}, "Bonjour à tous", 1},
+	2: {"Hello\n\nworld\n", AlignAuto,
+		dictTranslator{"⟦0⟧ Hello\n⟦1⟧ world": " ⟦0⟧ Bonjour\n\n⟦1⟧le monde"}, "Bonjour\n\nle monde\n", 1},
+	3: {"Hello\nworld", AlignAuto,
+		dictTranslator{"⟦0⟧ Hello\n⟦1⟧ world": "⟦0⟧ Bonjour le monde", "Hello": "Bonjour", "world": "le monde"},
+		"Bonjour\nle monde", 3},
+	4: {"Hello\nworld", AlignAuto,
+		dictTranslator{"⟦0⟧ Hello\n⟦1⟧ world": "⟦1⟧ le monde ⟦0⟧ Bonjour", "Hello": "Bonjour", "world": "le monde"},
+		"Bonjour\nle monde", 3},
+	5: {"Hello\nworld", AlignAuto,
+		dictTranslator{"⟦0⟧ Hello\n⟦1⟧ world": "Bonjour ⟦0⟧ ⟦1⟧ le monde", "Hello": "Bonjour", "world": "le monde"},
+		"Bonjour\nle monde", 3},
+	6: {"Hello\nworld", AlignMarkers, dictTranslator{"⟦0⟧ Hello\n⟦1⟧ world": "⟦0⟧ Bonjour le monde"}, "", -1},
+	7: {"Hello\nworld", AlignLines,
+		dictTranslator{"Hello": "Bonjour", "world": "le monde"}, "Bonjour\nle monde", 2},
+	8: {"a ⟦0⟧\nb", AlignAuto, dictTranslator{"a ⟦0⟧": "A ⟦0⟧", "b": "B"}, "A ⟦0⟧\nB", 2},
+}
+
+// callsTranslator is a dictTranslator counting its translations.
+type callsTranslator struct {
+	dictTranslator
+	calls int
+}
+
+func (c *callsTranslator) Translate(text, source, target string) (string, error) {
+	c.calls++
+	return c.dictTranslator.Translate(text, source, target)
+}
+
+func (c *callsTranslator) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	c.calls++
+	return c.dictTranslator.TranslateContext(ctx, text, source, target)
+}
+
+func TestAligner_Translate(t *testing.T) {
+	for i, tt := range alignertests {
+		tr := &callsTranslator{dictTranslator: tt.dict}
+		out, err := NewAligner(tr, tt.mode).Translate(tt.in, "en", "fr")
+		if tt.calls < 0 {
+			var ae *AlignmentError
+			if !errors.As(err, &ae) {
+				t.Errorf("#%d Translate(%q) have error: %v, want: AlignmentError", i, tt.in, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d Translate(%q) have error: %s, want error: nil", i, tt.in, err)
+			continue
+		}
+		if out != tt.out || tr.calls != tt.calls {
+			t.Errorf("#%d Translate(%q) = %q by %d calls, want: %q by %d calls",
+				i, tt.in, out, tr.calls, tt.out, tt.calls)
+		}
+	}
+}
+
+func TestAligner_Protector(t *testing.T) {
+	// The markers are protected as placeholders, and lost by the
+	// translator.
+	dict := dictTranslator{"⟦0⟧ ⟦1⟧Hi⟦2⟧\n⟦3⟧ there": "⟦0⟧ ⟦1⟧Salut⟦2⟧ là", "⟦0⟧Hi⟦1⟧": "⟦0⟧Salut⟦1⟧", "there": "là"}
+	tr := NewAligner(NewProtector(dict), AlignAuto)
+	out, err := tr.Translate("<b>Hi</b>\nthere", "en", "fr")
+	if err != nil || out != "<b>Salut</b>\nlà" {
+		t.Errorf("Translate() = (%q, %v), want: (%q, nil)", out, err, "<b>Salut</b>\nlà")
+	}
+}
+
+func TestAligner_WithoutAlignment(t *testing.T) {
+	tr := &callsTranslator{dictTranslator: dictTranslator{"a  b\nc": "A  B\nC"}}
+	ctx := WithoutAlignment(context.Background())
+	r, err := TranslateResult(ctx, NewAligner(tr, AlignLines), "a  b\nc", "en", "fr")
+	if err != nil || r.Text != "A  B\nC" || tr.calls != 1 {
+		t.Errorf("TranslateResult() = (%+v, %v) by %d calls, want: A  B\\nC by 1 call", r, err, tr.calls)
+	}
+}
+
+func TestParseAlignMode(t *testing.T) {
+	for _, m := range []AlignMode{AlignAuto, AlignMarkers, AlignLines} {
+		if have, err := ParseAlignMode(m.String()); err != nil || have != m {
+			t.Errorf("ParseAlignMode(%q) = (%v, %v), want: (%v, nil)", m.String(), have, err, m)
+		}
+	}
+	if _, err := ParseAlignMode("sentences"); err == nil {
+		t.Errorf("ParseAlignMode(%q) have error: nil, want: error", "sentences")
+	}
+}
@@ -0,0 +1,217 @@
+package tran
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchOptions configures TranslateBatch and TranslateBatchFunc.
+type BatchOptions struct {
+	// Parallel is the maximum number of concurrent translations.
+	// Values less than 1 mean 1.
+	Parallel int
+
+	// RequestsPerSecond limits the rate at which translations are
+	// started. Zero or a negative number means no limit.
+	RequestsPerSecond float64
+}
+
+// DefaultBatchOptions is the BatchOptions used when none is configured.
+var DefaultBatchOptions = BatchOptions{
+	Parallel:          4,
+	RequestsPerSecond: 0,
+}
+
+// TranslateBatch translates texts concurrently according to opts, and
+// returns the translations in the order of texts. A nil opts means
+// DefaultBatchOptions. It stops at the first error.
+func TranslateBatch(ctx context.Context, tr Translator, texts []string,
+	source, target string, opts *BatchOptions) ([]string, error) {
+	outs := make([]string, len(texts))
+	err := TranslateBatchFunc(ctx, tr, texts, source, target, opts,
+		func(i int, out string) error {
+			outs[i] = out
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return outs, nil
+}
+
+// TranslateBatchFunc is like TranslateBatch, but instead of returning
+// the translations it calls fn with each of them in the order of texts,
+// as soon as the translation and all the preceding ones are done.
+// If fn returns an error, TranslateBatchFunc stops and returns it.
+func TranslateBatchFunc(ctx context.Context, tr Translator, texts []string,
+	source, target string, opts *BatchOptions, fn func(i int, out string) error) error {
+	return TranslateBatchResultFunc(ctx, tr, texts, source, target, opts,
+		func(i int, r *Result) error {
+			return fn(i, r.Text)
+		})
+}
+
+// TranslateBatchResultFunc is like TranslateBatchFunc, but calls fn with
+// the Result of each translation, as returned by TranslateResult.
+func TranslateBatchResultFunc(ctx context.Context, tr Translator, texts []string,
+	source, target string, opts *BatchOptions, fn func(i int, r *Result) error) error {
+	return TranslateBatchItemFunc(ctx, tr, texts, source, target, opts,
+		func(i int, item *BatchItem) error {
+			if item.Err != nil {
+				return item.Err
+			}
+			return fn(i, item.Result)
+		})
+}
+
+// BatchItem is the outcome of the translation of a text of a batch.
+type BatchItem struct {
+	Result  *Result       // nil if Err is not nil
+	Err     error         // of the translation
+	Elapsed time.Duration // by the translation, without the rate limit
+}
+
+// TranslateBatchItemFunc is like TranslateBatchResultFunc, but calls fn
+// with the outcome of each translation, even if it fails, and goes on
+// to the rest. It stops at the first error returned by fn, or when ctx
+// is done.
+func TranslateBatchItemFunc(ctx context.Context, tr Translator, texts []string,
+	source, target string, opts *BatchOptions, fn func(i int, item *BatchItem) error) error {
+	ch := make(chan string, len(texts))
+	for _, s := range texts {
+		ch <- s
+	}
+	close(ch)
+	return TranslateStreamFunc(ctx, tr, ch, source, target, opts, fn)
+}
+
+// TranslateStreamFunc is like TranslateBatchItemFunc, but translates the
+// texts received from texts until it is closed, as soon as they are
+// received. The i-th text received is the i-th of the batch, and the
+// rate limit of opts holds for all of them. The texts are no longer
+// received after it returns.
+func TranslateStreamFunc(ctx context.Context, tr Translator, texts <-chan string,
+	source, target string, opts *BatchOptions, fn func(i int, item *BatchItem) error) error {
+	if opts == nil {
+		opts = &DefaultBatchOptions
+	}
+	parallel := opts.Parallel
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type job struct {
+		text   string
+		result chan *BatchItem
+	}
+	jobs := make(chan job)
+	// results are the channels of the results of the jobs in order.
+	results := make(chan chan *BatchItem, parallel)
+	lim := newLimiter(opts.RequestsPerSecond)
+
+	var wg sync.WaitGroup
+	for n := 0; n < parallel; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if err := lim.wait(ctx); err != nil {
+					j.result <- &BatchItem{Err: err}
+					continue
+				}
+				start := time.Now()
+				r, err := TranslateResult(ctx, tr, j.text, source, target)
+				j.result <- &BatchItem{Result: r, Err: err, Elapsed: time.Since(start)}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		defer close(results)
+		for {
+			var j job
+			select {
+			case text, ok := <-texts:
+				if !ok {
+					return
+				}
+				j = job{text, make(chan *BatchItem, 1)}
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case results <- j.result:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	for i := 0; ; i++ {
+		var result chan *BatchItem
+		select {
+		case r, ok := <-results:
+			if !ok {
+				return ctx.Err()
+			}
+			result = r
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		var item *BatchItem
+		select {
+		case item = <-result:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if err := fn(i, item); err != nil {
+			return err
+		}
+	}
+}
+
+// limiter spaces the start of operations evenly at a fixed rate.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newLimiter(perSecond float64) *limiter {
+	l := &limiter{}
+	if perSecond > 0 {
+		l.interval = time.Duration(float64(time.Second) / perSecond)
+	}
+	return l
+}
+
+func (l *limiter) wait(ctx context.Context) error {
+	if l.interval <= 0 {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	d := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+	if d <= 0 {
+		return ctx.Err()
+	}
+	return sleepContext(ctx, d)
+}
@@ -0,0 +1,161 @@
+package tran
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowTranslator upper-cases texts, taking longer for earlier texts so
+// that translations finish out of order.
+type slowTranslator struct {
+	dictTranslator
+	running, peak int32
+}
+
+func (s *slowTranslator) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	n := atomic.AddInt32(&s.running, 1)
+	defer atomic.AddInt32(&s.running, -1)
+	for {
+		p := atomic.LoadInt32(&s.peak)
+		if n <= p || atomic.CompareAndSwapInt32(&s.peak, p, n) {
+			break
+		}
+	}
+	if text == "fail" {
+		return "", errors.New("failed")
+	}
+	d := time.Duration(10-len(text)) * 2 * time.Millisecond
+	if err := sleepContext(ctx, d); err != nil {
+		return "", err
+	}
+	return strings.ToUpper(text), nil
+}
+
+type TranslateBatchTest struct {
+	texts    []string
+	parallel int
+	outs     []string
+	err      string
+}
+
+var translatebatchtests = []TranslateBatchTest{
+	0: {[]string{}, 4, []string{}, ""},
+	1: {[]string{"a", "bb", "ccc", "dddd", "eeeee"}, 1,
+		[]string{"A", "BB", "CCC", "DDDD", "EEEEE"}, ""},
+	2: {[]string{"a", "bb", "ccc", "dddd", "eeeee"}, 3,
+		[]string{"A", "BB", "CCC", "DDDD", "EEEEE"}, ""},
+	3: {[]string{"a", "bb", "fail", "dddd", "eeeee"}, 2, nil, "failed"},
+}
+
+func TestTranslateBatch(t *testing.T) {
+	for i, tt := range translatebatchtests {
+		tr := &slowTranslator{}
+		opts := &BatchOptions{Parallel: tt.parallel}
+		outs, err := TranslateBatch(context.Background(), tr, tt.texts, "", "en", opts)
+		if err != nil {
+			if tt.err == "" || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("#%d have error: %s, want error: %q", i, err, tt.err)
+			}
+			continue
+		}
+		if tt.err != "" {
+			t.Errorf("#%d have error: nil, want error: %q", i, tt.err)
+			continue
+		}
+		if strings.Join(outs, ",") != strings.Join(tt.outs, ",") {
+			t.Errorf("#%d TranslateBatch() = %q, want: %q", i, outs, tt.outs)
+		}
+		if tr.peak > int32(tt.parallel) {
+			t.Errorf("#%d have peak concurrency: %d, want: <= %d",
+				i, tr.peak, tt.parallel)
+		}
+	}
+}
+
+func TestTranslateBatchFunc_Order(t *testing.T) {
+	texts := []string{"a", "bb", "ccc", "dddd"}
+	var got []int
+	err := TranslateBatchFunc(context.Background(), &slowTranslator{}, texts,
+		"", "en", &BatchOptions{Parallel: 4},
+		func(i int, out string) error {
+			got = append(got, i)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("have error: %s, want error: nil", err)
+	}
+	for i, n := range got {
+		if i != n {
+			t.Fatalf("fn called in order %v, want: [0 1 2 3]", got)
+		}
+	}
+}
+
+func TestTranslateBatchItemFunc(t *testing.T) {
+	texts := []string{"a", "fail", "ccc"}
+	var have []string
+	err := TranslateBatchItemFunc(context.Background(), &slowTranslator{}, texts,
+		"", "en", &BatchOptions{Parallel: 2},
+		func(i int, item *BatchItem) error {
+			if item.Err != nil {
+				have = append(have, fmt.Sprintf("%d:%s", i, item.Err))
+				return nil
+			}
+			if item.Elapsed <= 0 {
+				t.Errorf("#%d have elapsed: %v, want: > 0", i, item.Elapsed)
+			}
+			have = append(have, fmt.Sprintf("%d:%s", i, item.Result.Text))
+			return nil
+		})
+	if want := "[0:A 1:failed 2:CCC]"; err != nil || fmt.Sprint(have) != want {
+		t.Errorf("TranslateBatchItemFunc() = (%v, %v), want: (%s, nil)", have, err, want)
+	}
+}
+
+func TestTranslateBatch_RequestsPerSecond(t *testing.T) {
+	texts := []string{"a", "b", "c", "d", "e"}
+	opts := &BatchOptions{Parallel: 5, RequestsPerSecond: 100}
+	start := time.Now()
+	_, err := TranslateBatch(context.Background(), dictTranslator{}, texts, "", "en", opts)
+	if err != nil {
+		t.Fatalf("have error: %s, want error: nil", err)
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("5 requests at 100/s took %v, want: >= 40ms", d)
+	}
+}
+
+func TestTranslateStreamFunc(t *testing.T) {
+	texts := make(chan string)
+	go func() {
+		texts <- "a"
+	}()
+	opts := &BatchOptions{Parallel: 5, RequestsPerSecond: 100}
+	start := time.Now()
+	var have []string
+	err := TranslateStreamFunc(context.Background(), dictTranslator{}, texts, "", "en", opts,
+		func(i int, item *BatchItem) error {
+			if item.Err != nil {
+				return item.Err
+			}
+			have = append(have, fmt.Sprintf("%d:%s", i, item.Result.Text))
+			// The next text is sent after the translation of the last.
+			if i < 4 {
+				go func() { texts <- strings.Repeat("b", i+1) }()
+			} else {
+				close(texts)
+			}
+			return nil
+		})
+	if want := "[0:a 1:b 2:bb 3:bbb 4:bbbb]"; err != nil || fmt.Sprint(have) != want {
+		t.Errorf("TranslateStreamFunc() = (%v, %v), want: (%s, nil)", have, err, want)
+	}
+	if d := time.Since(start); d < 40*time.Millisecond {
+		t.Errorf("5 requests at 100/s took %v, want: >= 40ms", d)
+	}
+}
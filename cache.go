@@ -0,0 +1,331 @@
+package tran
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CacheOptions configures a Cache.
+type CacheOptions struct {
+	// Namespace separates the entries of different backends sharing
+	// a cache file, such as the URL of the endpoint.
+	Namespace string
+
+	// MaxEntries limits the number of entries. The least recently used
+	// entries are evicted when the cache is saved. Zero means no limit.
+	MaxEntries int
+
+	// TTL is the lifetime of entries. Zero means entries never expire.
+	TTL time.Duration
+}
+
+// DefaultCacheOptions is the CacheOptions used when none is configured.
+var DefaultCacheOptions = CacheOptions{
+	MaxEntries: 10000,
+	TTL:        30 * 24 * time.Hour,
+}
+
+// CacheEntry is a translation stored in a Cache.
+type CacheEntry struct {
+	Namespace  string    `json:"namespace"`
+	Source     string    `json:"source"`
+	Target     string    `json:"target"`
+	Text       string    `json:"text"`
+	Translated string    `json:"translated"`
+	Detected   string    `json:"detected,omitempty"`
+	Created    time.Time `json:"created"`
+	Accessed   time.Time `json:"accessed"`
+}
+
+func (e *CacheEntry) key() string {
+	return cacheKey(e.Namespace, e.Source, e.Target, e.Text)
+}
+
+func cacheKey(namespace, source, target, text string) string {
+	h := sha256.New()
+	for _, s := range []string{namespace, source, target, text} {
+		io.WriteString(h, s)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CacheStats holds statistics of a Cache.
+type CacheStats struct {
+	Path    string
+	Entries int
+	Expired int
+	Bytes   int64 // size of the cache file
+	Hits    int   // since the cache was opened
+	Misses  int   // since the cache was opened
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+// Cache is a Translator which stores the translations of the
+// underlying Translator in a file, and reuses them for the same
+// text, source and target.
+type Cache struct {
+	Translator
+
+	path string
+	opts CacheOptions
+
+	mu      sync.Mutex
+	entries map[string]*CacheEntry
+	dirty   bool
+	hits    int
+	misses  int
+	now     func() time.Time
+}
+
+// OpenCache returns a Cache of tr stored in the file at path.
+// The file is created when the cache is saved if it does not exist.
+// A nil opts means DefaultCacheOptions.
+func OpenCache(tr Translator, path string, opts *CacheOptions) (*Cache, error) {
+	c := &Cache{
+		Translator: tr,
+		path:       path,
+		opts:       DefaultCacheOptions,
+		entries:    map[string]*CacheEntry{},
+		now:        time.Now,
+	}
+	if opts != nil {
+		c.opts = *opts
+	}
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []*CacheEntry
+	if len(buf) > 0 {
+		if err := json.Unmarshal(buf, &entries); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for _, e := range entries {
+		c.entries[e.key()] = e
+	}
+	return c, nil
+}
+
+func (c *Cache) Translate(text, source, target string) (string, error) {
+	return c.TranslateContext(context.Background(), text, source, target)
+}
+
+func (c *Cache) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	r, err := c.TranslateResult(ctx, text, source, target)
+	if err != nil {
+		return "", err
+	}
+	return r.Text, nil
+}
+
+// TranslateResult is like TranslateContext, but also returns the source
+// language detected by the underlying Translator, which is cached with
+// the translation. The pseudo-localizations are cached only for
+// PseudoTarget.
+func (c *Cache) TranslateResult(ctx context.Context, text, source, target string) (*Result, error) {
+	if e, ok := c.lookup(text, source, target); ok {
+		r := &Result{Text: e.Translated, Source: source, Cached: true}
+		if r.Source == "" {
+			r.Source = e.Detected
+		}
+		return r, nil
+	}
+	r, err := TranslateResult(ctx, c.Translator, text, source, target)
+	if err != nil {
+		return nil, err
+	}
+	if !r.reusable(target) {
+		return r, nil
+	}
+	detected := ""
+	if source == "" {
+		detected = r.Source
+	}
+	c.store(text, source, target, r.Text, detected)
+	return r, nil
+}
+
+func (c *Cache) expired(e *CacheEntry, now time.Time) bool {
+	return c.opts.TTL > 0 && now.Sub(e.Created) > c.opts.TTL
+}
+
+// Lookup returns the cached translation of text, if any.
+func (c *Cache) Lookup(text, source, target string) (string, bool) {
+	e, ok := c.lookup(text, source, target)
+	if !ok {
+		return "", false
+	}
+	return e.Translated, true
+}
+
+func (c *Cache) lookup(text, source, target string) (CacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	e, ok := c.entries[cacheKey(c.opts.Namespace, source, target, text)]
+	if !ok || c.expired(e, now) {
+		c.misses++
+		return CacheEntry{}, false
+	}
+	c.hits++
+	e.Accessed = now
+	c.dirty = true
+	return *e, true
+}
+
+// Store adds the translation of text to the cache.
+func (c *Cache) Store(text, source, target, translated string) {
+	c.store(text, source, target, translated, "")
+}
+
+func (c *Cache) store(text, source, target, translated, detected string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	e := &CacheEntry{
+		Namespace:  c.opts.Namespace,
+		Source:     source,
+		Target:     target,
+		Text:       text,
+		Translated: translated,
+		Detected:   detected,
+		Created:    now,
+		Accessed:   now,
+	}
+	c.entries[e.key()] = e
+	c.dirty = true
+}
+
+// Clear removes all the entries of the cache.
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[string]*CacheEntry{}
+	c.dirty = true
+}
+
+// Entries returns the entries of the cache, oldest first.
+func (c *Cache) Entries() []*CacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sortedEntries()
+}
+
+func (c *Cache) sortedEntries() []*CacheEntry {
+	a := make([]*CacheEntry, 0, len(c.entries))
+	for _, e := range c.entries {
+		a = append(a, e)
+	}
+	sort.Slice(a, func(i, j int) bool {
+		if a[i].Created.Equal(a[j].Created) {
+			return a[i].key() < a[j].key()
+		}
+		return a[i].Created.Before(a[j].Created)
+	})
+	return a
+}
+
+// Export writes the entries of the cache to w as JSON Lines.
+func (c *Cache) Export(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, e := range c.Entries() {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats returns the statistics of the cache.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := CacheStats{
+		Path:    c.path,
+		Entries: len(c.entries),
+		Hits:    c.hits,
+		Misses:  c.misses,
+	}
+	if fi, err := os.Stat(c.path); err == nil {
+		st.Bytes = fi.Size()
+	}
+	now := c.now()
+	for _, e := range c.entries {
+		if c.expired(e, now) {
+			st.Expired++
+		}
+		if st.Oldest.IsZero() || e.Created.Before(st.Oldest) {
+			st.Oldest = e.Created
+		}
+		if e.Created.After(st.Newest) {
+			st.Newest = e.Created
+		}
+	}
+	return st
+}
+
+// Save writes the cache to its file if it has been changed, after
+// removing expired entries and evicting the least recently used ones
+// beyond MaxEntries.
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	now := c.now()
+	for k, e := range c.entries {
+		if c.expired(e, now) {
+			delete(c.entries, k)
+		}
+	}
+	if c.opts.MaxEntries > 0 && len(c.entries) > c.opts.MaxEntries {
+		a := c.sortedEntries()
+		sort.SliceStable(a, func(i, j int) bool {
+			return a[i].Accessed.Before(a[j].Accessed)
+		})
+		for _, e := range a[:len(a)-c.opts.MaxEntries] {
+			delete(c.entries, e.key())
+		}
+	}
+
+	buf, err := json.Marshal(c.sortedEntries())
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(c.path), ".cache-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,159 @@
+package tran
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type countTranslator struct {
+	dictTranslator
+	calls int
+}
+
+func (c *countTranslator) Translate(text, source, target string) (string, error) {
+	return c.TranslateContext(context.Background(), text, source, target)
+}
+
+func (c *countTranslator) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	c.calls++
+	return strings.ToUpper(text) + "@" + target, nil
+}
+
+func tempCachePath(t *testing.T) (path string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "tran-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "cache.json"), func() { os.RemoveAll(dir) }
+}
+
+func TestCache_Translate(t *testing.T) {
+	path, cleanup := tempCachePath(t)
+	defer cleanup()
+
+	tr := &countTranslator{}
+	c, err := OpenCache(tr, path, &CacheOptions{Namespace: "ep1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"CAT@ja", "CAT@ja", "CAT@de"} {
+		target := "ja"
+		if i == 2 {
+			target = "de"
+		}
+		out, err := c.Translate("cat", "", target)
+		if err != nil || out != want {
+			t.Errorf("#%d Translate() = (%q, %v), want: (%q, nil)", i, out, err, want)
+		}
+	}
+	if tr.calls != 2 {
+		t.Errorf("have calls: %d, want: 2", tr.calls)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopened in the same namespace
+	tr = &countTranslator{}
+	c, err = OpenCache(tr, path, &CacheOptions{Namespace: "ep1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Translate("cat", "", "ja")
+	if tr.calls != 0 {
+		t.Errorf("reopened: have calls: %d, want: 0", tr.calls)
+	}
+	st := c.Stats()
+	if st.Entries != 2 || st.Hits != 1 || st.Misses != 0 || st.Bytes == 0 {
+		t.Errorf("Stats() = %+v, want: 2 entries, 1 hit, 0 misses", st)
+	}
+
+	// Reopened in another namespace
+	c, err = OpenCache(tr, path, &CacheOptions{Namespace: "ep2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Translate("cat", "", "ja")
+	if tr.calls != 1 {
+		t.Errorf("other namespace: have calls: %d, want: 1", tr.calls)
+	}
+}
+
+func TestCache_TranslateResult(t *testing.T) {
+	path, cleanup := tempCachePath(t)
+	defer cleanup()
+	s := newAPIServer()
+	defer s.Close()
+	s.AddDetection("猫", "ja")
+
+	c, err := OpenCache(NewRetrier(NewAPI(s.URL), RetryPolicy{}), path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		r, err := TranslateResult(context.Background(), c, "猫", "", "en")
+		want := Result{Text: "Cat", Source: "ja", Cached: i > 0}
+		if err != nil || !reflect.DeepEqual(*r, want) {
+			t.Errorf("#%d TranslateResult() = (%+v, %v), want: (%+v, nil)", i, r, err, want)
+		}
+	}
+	if n := len(s.Requests()); n != 1 {
+		t.Errorf("have requests: %d, want: 1", n)
+	}
+}
+
+func TestCache_Save(t *testing.T) {
+	path, cleanup := tempCachePath(t)
+	defer cleanup()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c, err := OpenCache(&countTranslator{}, path,
+		&CacheOptions{MaxEntries: 2, TTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.now = func() time.Time { return now }
+	c.Translate("a", "", "ja") // expires
+	now = now.Add(59 * time.Minute)
+	c.Translate("b", "", "ja") // evicted
+	c.Translate("c", "", "ja")
+	c.Translate("d", "", "ja")
+	now = now.Add(2 * time.Minute)
+	c.Translate("c", "", "ja")
+	if st := c.Stats(); st.Entries != 4 || st.Expired != 1 {
+		t.Errorf("Stats() = %+v, want: 4 entries, 1 expired", st)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 ||
+		!strings.Contains(lines[0], `"text":"c"`) ||
+		!strings.Contains(lines[1], `"text":"d"`) {
+		t.Errorf("Export() = \n%s\nwant: entries of c and d", buf.String())
+	}
+
+	c.Clear()
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	c, err = OpenCache(&countTranslator{}, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(c.Entries()); n != 0 {
+		t.Errorf("cleared: have entries: %d, want: 0", n)
+	}
+}
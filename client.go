@@ -0,0 +1,177 @@
+package tran
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// send sends an HTTP request according to o, and returns the response
+// with its body read.
+func (o *Options) send(ctx context.Context, method, url, contentType string,
+	body io.Reader) (*http.Response, []byte, error) {
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, vs := range o.Header {
+		for _, s := range vs {
+			req.Header.Add(k, s)
+		}
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if o.UserAgent != "" {
+		req.Header.Set("User-Agent", o.UserAgent)
+	}
+
+	hc := o.Client
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, buf, nil
+}
+
+func httpError(resp *http.Response) *HTTPError {
+	return &HTTPError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+func parseRetryAfter(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	if n, err := strconv.Atoi(s); err == nil && n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	if t, err := http.ParseTime(s); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// Options configures how a Client talks to its Endpoint.
+type Options struct {
+	// Client is the HTTP client used for requests.
+	// If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// Timeout limits the time of each request. Zero means no timeout
+	// other than the one of the context.
+	Timeout time.Duration
+
+	// UserAgent is sent as the User-Agent header if not empty.
+	UserAgent string
+
+	// Header holds extra headers sent with each request.
+	Header http.Header
+}
+
+// Client is a Translator which sends requests to an Endpoint
+// according to its Options.
+type Client struct {
+	Endpoint Endpoint
+	Options  Options
+}
+
+// NewClient returns a Client for ep. A nil opts means the zero Options.
+func NewClient(ep Endpoint, opts *Options) *Client {
+	c := &Client{Endpoint: ep}
+	if opts != nil {
+		c.Options = *opts
+	}
+	return c
+}
+
+func (c *Client) Translate(text, source, target string) (string, error) {
+	return c.TranslateContext(context.Background(), text, source, target)
+}
+
+func (c *Client) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	r, err := c.TranslateResult(ctx, text, source, target)
+	if err != nil {
+		return "", err
+	}
+	return r.Text, nil
+}
+
+// TranslateResult is like TranslateContext, but also returns the source
+// language detected by the API script if source is empty.
+func (c *Client) TranslateResult(ctx context.Context, text, source, target string) (*Result, error) {
+	v := url.Values{}
+	v.Add("text", text)
+	v.Add("source", source)
+	v.Add("target", target)
+
+	resp, buf, err := c.Options.send(ctx, http.MethodPost, string(c.Endpoint),
+		"application/x-www-form-urlencoded", strings.NewReader(v.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, httpError(resp)
+	}
+	var td TransData
+	if err := json.Unmarshal(buf, &td); err != nil {
+		return nil, invalidResponse(err)
+	}
+	if td.Code != 200 {
+		msg := td.Message
+		prefix := "exception:"
+		if strings.HasPrefix(strings.ToLower(msg), prefix) {
+			msg = string(msg[len(prefix):])
+			msg = strings.TrimSpace(msg)
+		}
+		return nil, &APIError{Code: td.Code, Message: msg}
+	}
+	r := &Result{Text: td.Text, Source: source}
+	if r.Source == "" {
+		r.Source = td.Source
+	}
+	return r, nil
+}
+
+func (c *Client) Languages() (ISO639List, error) {
+	return c.Endpoint.Languages()
+}
+
+// Detect translates text into English to get the language detected by
+// the API script. It returns ErrNotSupported if the script does not
+// report the detected language.
+func (c *Client) Detect(text string) (string, error) {
+	r, err := c.TranslateResult(context.Background(), text, "", "en")
+	if err != nil {
+		return "", err
+	}
+	if r.Source == "" {
+		return "", ErrNotSupported
+	}
+	return r.Source, nil
+}
@@ -0,0 +1,93 @@
+package tran
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_TranslateContext(t *testing.T) {
+	s := newAPIServer()
+	defer s.Close()
+
+	c := NewClient(Endpoint(s.URL), &Options{
+		UserAgent: "tran-test",
+		Header:    http.Header{"X-Extra": {"1"}},
+	})
+	out, err := c.TranslateContext(context.Background(), "猫", "", "de")
+	if err != nil {
+		t.Fatalf("have error: %s, want error: nil", err)
+	}
+	if out != "Katze" {
+		t.Errorf("TranslateContext() = %q, want: %q", out, "Katze")
+	}
+	reqs := s.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("have requests: %d, want: 1", len(reqs))
+	}
+	r := reqs[0]
+	ua, extra := r.Header.Get("User-Agent"), r.Header.Get("X-Extra")
+	if ua != "tran-test" || extra != "1" || r.Text != "猫" || r.Target != "de" {
+		t.Errorf("request = (%q, %q, %q, %q), want: (%q, %q, %q, %q)",
+			ua, extra, r.Text, r.Target, "tran-test", "1", "猫", "de")
+	}
+}
+
+func TestClient_TranslateError(t *testing.T) {
+	s := newAPIServer()
+	defer s.Close()
+
+	_, err := NewClient(Endpoint(s.URL), nil).Translate("Cat", "", "xx")
+	if err == nil || err.Error() != "Invalid argument: target" {
+		t.Errorf("have error: %v, want error: %s", err, "Invalid argument: target")
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	s := newAPIServer()
+	defer s.Close()
+	s.SetLatency(5 * time.Second)
+
+	c := NewClient(Endpoint(s.URL), &Options{Timeout: 50 * time.Millisecond})
+	_, err := c.Translate("Cat", "", "ja")
+	if err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("have error: %v, want error: deadline exceeded", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewClient(Endpoint(s.URL), nil).TranslateContext(ctx, "Cat", "", "ja")
+	if err == nil || !strings.Contains(err.Error(), "canceled") {
+		t.Errorf("have error: %v, want error: canceled", err)
+	}
+}
+
+func TestClient_TranslateResult(t *testing.T) {
+	s := newAPIServer()
+	defer s.Close()
+	s.AddDetection("猫", "ja")
+	c := NewClient(Endpoint(s.URL), nil)
+
+	r, err := c.TranslateResult(context.Background(), "猫", "", "de")
+	if err != nil || !reflect.DeepEqual(*r, Result{Text: "Katze", Source: "ja"}) {
+		t.Errorf("TranslateResult() = (%+v, %v), want: ({Katze ja}, nil)", r, err)
+	}
+	r, err = c.TranslateResult(context.Background(), "猫", "zh", "de")
+	if err != nil || !reflect.DeepEqual(*r, Result{Text: "Katze", Source: "zh"}) {
+		t.Errorf("TranslateResult() = (%+v, %v), want: ({Katze zh}, nil)", r, err)
+	}
+	if reqs := s.Requests(); reqs[len(reqs)-1].Source != "zh" {
+		t.Errorf("have request: %+v, want: source zh", reqs[len(reqs)-1])
+	}
+
+	code, err := c.Detect("猫")
+	if err != nil || code != "ja" {
+		t.Errorf("Detect() = (%q, %v), want: (%q, nil)", code, err, "ja")
+	}
+	if _, err := c.Detect("Cat"); err != ErrNotSupported {
+		t.Errorf("Detect() have error: %v, want error: %v", err, ErrNotSupported)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/y-bash/go-tran"
+)
+
+var cache *tran.Cache
+
+func openCache() {
+	c, err := tran.OpenCache(cfg.Translator, cfg.CachePath, &cfg.CacheOptions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "GO-TRAN: cache is disabled: %s\n", err)
+		return
+	}
+	cache = c
+	cfg.Translator = c
+}
+
+func saveCache() {
+	if cache == nil {
+		return
+	}
+	if err := cache.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func cacheStatsTo(w io.Writer, st tran.CacheStats) {
+	fmt.Fprintf(w, "Path:     %s\n", st.Path)
+	fmt.Fprintf(w, "Entries:  %d (%d expired)\n", st.Entries, st.Expired)
+	fmt.Fprintf(w, "Size:     %d bytes\n", st.Bytes)
+	fmt.Fprintf(w, "Oldest:   %s\n", formatTime(st.Oldest))
+	fmt.Fprintf(w, "Newest:   %s\n", formatTime(st.Newest))
+}
+
+func commandCache(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage:  tran cache stats|clear|export")
+		return exitUsage
+	}
+	c, err := tran.OpenCache(cfg.Translator, cfg.CachePath, &cfg.CacheOptions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+		return exitFailure
+	}
+	switch args[0] {
+	case "stats":
+		cacheStatsTo(os.Stdout, c.Stats())
+	case "clear":
+		c.Clear()
+		if err := c.Save(); err != nil {
+			fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+			return exitFailure
+		}
+	case "export":
+		if err := c.Export(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+			return exitFailure
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "GO-TRAN: %s: Unknown cache command\n", args[0])
+		return exitUsage
+	}
+	return exitOK
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/y-bash/go-tran"
+)
+
+// glossariesFor returns the glossaries of cfg for the language pair.
+func glossariesFor(source, target string) []*tran.Glossary {
+	var gs []*tran.Glossary
+	for _, g := range cfg.Glossaries {
+		if g.Target == target && (g.Source == "" || source == "" || g.Source == source) {
+			gs = append(gs, g)
+		}
+	}
+	return gs
+}
+
+// checkGlossary writes the violations of gs in each line of translation
+// of the same line of source, and returns the number of them.
+func checkGlossary(w io.Writer, gs []*tran.Glossary, source, translation io.Reader, name string) (n int, err error) {
+	ss, ts := bufio.NewScanner(source), bufio.NewScanner(translation)
+	for line := 1; ; line++ {
+		sok, tok := ss.Scan(), ts.Scan()
+		if !sok || !tok {
+			if err = ss.Err(); err == nil {
+				err = ts.Err()
+			}
+			if err == nil && sok != tok {
+				err = fmt.Errorf("%s:%d: Number of lines differs from the source", name, line)
+			}
+			return n, err
+		}
+		for _, g := range gs {
+			for _, v := range g.Check(ss.Text(), ts.Text()) {
+				fmt.Fprintf(w, "%s:%d: %s\n", name, line, v)
+				n++
+			}
+		}
+	}
+}
+
+func commandGlossary(args []string) int {
+	if len(args) != 3 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "Usage:  tran [-s CODE] [-t CODE] glossary check SOURCE TRANSLATION")
+		return exitUsage
+	}
+	gs := glossariesFor(cfg.DefaultSourceCode, cfg.DefaultTargetCode)
+	if len(gs) == 0 {
+		fmt.Fprintf(os.Stderr, "GO-TRAN: No glossary for %s in config.toml\n", cfg.DefaultTargetName)
+		return exitFailure
+	}
+	var files [2]*os.File
+	for i, path := range args[1:] {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+			return exitFailure
+		}
+		defer f.Close()
+		files[i] = f
+	}
+	n, err := checkGlossary(os.Stdout, gs, files[0], files[1], args[2])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+		return exitFailure
+	}
+	if n > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math"
+	"time"
+
+	"github.com/y-bash/go-tran"
+	docfmt "github.com/y-bash/go-tran/format"
+)
+
+// segmentJSON is the translation of a segment written by the options
+// --json and --jsonl.
+type segmentJSON struct {
+	File       string      `json:"file,omitempty"`
+	Index      int         `json:"index"`
+	Text       string      `json:"text"`
+	Translated string      `json:"translated"`
+	Source     string      `json:"source"`
+	Target     string      `json:"target"`
+	Detected   string      `json:"detected,omitempty"`
+	Cached     bool        `json:"cached"`
+	ElapsedMS  float64     `json:"elapsed_ms"`
+	Fuzzy      []fuzzyJSON `json:"fuzzy,omitempty"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// fuzzyJSON is a fuzzy match of a segment in the translation memory.
+type fuzzyJSON struct {
+	Text       string  `json:"text"`
+	Translated string  `json:"translated"`
+	Source     string  `json:"source"`
+	Score      float64 `json:"score"`
+}
+
+// jsonOutput writes the segments as a JSON array, or as JSON Lines as
+// soon as they are translated.
+type jsonOutput struct {
+	w     io.Writer
+	lines bool
+	segs  []*segmentJSON
+}
+
+// jsonOut is set by the options --json and --jsonl.
+var jsonOut *jsonOutput
+
+func (o *jsonOutput) encoder() *json.Encoder {
+	enc := json.NewEncoder(o.w)
+	enc.SetEscapeHTML(false)
+	return enc
+}
+
+func (o *jsonOutput) add(s *segmentJSON) error {
+	if o.lines {
+		return o.encoder().Encode(s)
+	}
+	o.segs = append(o.segs, s)
+	return nil
+}
+
+// flush writes the JSON array of the segments added.
+func (o *jsonOutput) flush() error {
+	if o.lines {
+		return nil
+	}
+	segs := o.segs
+	if segs == nil {
+		segs = []*segmentJSON{}
+	}
+	enc := o.encoder()
+	enc.SetIndent("", "  ")
+	return enc.Encode(segs)
+}
+
+// translateJSON translates the segments of the document read from r, or
+// the chunks of the text if parse is nil, and adds them to jsonOut with
+// path. The texts and the translations are added with their masks
+// restored. The segments failing to be translated are added with their
+// errors, and the first of them is returned.
+func translateJSON(r io.Reader, path string, tr tran.Translator, parse docfmt.Parser) error {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	source := cfg.DefaultSourceCode
+	target := cfg.DefaultTargetCode
+	var texts []string
+	// unmask returns the segment i or its translation s to be written.
+	unmask := func(i int, s string) (string, error) { return s, nil }
+	if parse != nil {
+		doc, err := parse(buf, &docfmt.Options{
+			Source:    source,
+			Target:    target,
+			MergeCues: mergeCues,
+			Force:     force,
+			Include:   include,
+			Exclude:   exclude,
+		})
+		if err != nil {
+			return err
+		}
+		if source, target, err = documentLanguages(doc, source, target); err != nil {
+			return err
+		}
+		texts = doc.Segments()
+		unmask = doc.Unmask
+	} else {
+		for _, c := range tran.SplitChunks(string(buf), cfg.APILimitNChars) {
+			if c.Text != "" {
+				texts = append(texts, c.Text)
+			}
+		}
+	}
+	var first error
+	err = tran.TranslateBatchItemFunc(context.Background(), tr, texts, source, target, &cfg.APIBatch,
+		func(i int, item *tran.BatchItem) error {
+			s := &segmentJSON{
+				File:      path,
+				Index:     i,
+				Source:    source,
+				Target:    target,
+				ElapsedMS: math.Round(float64(item.Elapsed)/float64(time.Microsecond)) / 1000,
+			}
+			s.Text, _ = unmask(i, texts[i])
+			err := item.Err
+			if err == nil {
+				s.Translated, err = unmask(i, item.Result.Text)
+			}
+			if err != nil {
+				s.Error = err.Error()
+				if first == nil {
+					first = err
+				}
+			} else {
+				s.Cached = item.Result.Cached
+				if source == "" {
+					s.Detected = item.Result.Source
+				}
+				for _, m := range item.Result.Matches {
+					s.Fuzzy = append(s.Fuzzy, fuzzyJSON{
+						Text:       m.Entry.Text,
+						Translated: m.Entry.Translated,
+						Source:     m.Entry.Source,
+						Score:      math.Round(m.Score*1000) / 1000,
+					})
+				}
+			}
+			return jsonOut.add(s)
+		})
+	if err != nil {
+		return err
+	}
+	return first
+}
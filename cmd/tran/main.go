@@ -1,19 +1,25 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
 	"os"
 	"strings"
+	"sync"
 	"text/template"
+	"unicode"
 
 	"github.com/mattn/go-isatty"
 	"github.com/peterh/liner"
 	"github.com/y-bash/go-tran"
 	"github.com/y-bash/go-tran/config"
+	docfmt "github.com/y-bash/go-tran/format"
 )
 
 const version = "1.0.1"
@@ -32,7 +38,7 @@ function doPost(e) {
     try {
         const p = e.parameter
         const s = LanguageApp.translate(p.text, p.source, p.target)
-        body = {code: 200, text: s}
+        body = {code: 200, text: s, source: p.source || detect(p.text)}
     } catch (e) {
         try {
             const msg = LanguageApp.translate(e.toString(), "", "en")
@@ -47,6 +53,20 @@ function doPost(e) {
     resp.setContent(JSON.stringify(body))
 
     return resp;
+}
+
+// detect returns the language code of text, or "" if it is unknown,
+// since LanguageApp does not tell the language it detected.
+function detect(text) {
+    try {
+        const url = "https://translate.googleapis.com/translate_a/single" +
+            "?client=gtx&sl=auto&tl=en&dt=t&q=" +
+            encodeURIComponent(text.slice(0, 500))
+        const res = JSON.parse(UrlFetchApp.fetch(url).getContentText())
+        return res[2] || ""
+    } catch (e) {
+        return ""
+    }
 }`
 
 	fmt.Fprintln(os.Stderr, msg)
@@ -55,16 +75,84 @@ function doPost(e) {
 func helpToNonTerm() {
 	msg := `GO-TRAN (The language translator), version %s
 
-Usage:  tran [option...] [file...]
+Usage:  tran [option...] [--] [file...]
+        tran cache stats|clear|export
+        tran memory stats|clear|export|import FILE
+        tran [-s CODE] [-t CODE] glossary check SOURCE TRANSLATION
+
+    The files named cache, glossary or memory are translated if they
+    follow "--", such as "tran -- memory".
 
 Options:
     -a          show the script (Google Apps) for the API Server.
-    -e          echo the source text.
+    --align MODE
+                pair each source line with its translation for -e by
+                MODE: "auto" (default) translates the lines at once with
+                markers, or each line apart if the markers are lost,
+                "markers" fails if they are lost, and "lines" translates
+                each line apart.
+    -d          detect the language of the input without translation.
+    -e          echo the source text (and the detected language),
+                except for documents (see --format).
+    --exclude PATTERN,...
+                do not translate the values of the keys matching PATTERN
+                in structured files (json, yaml, toml), such as "**.url".
+    --format NAME
+                translate a document of NAME (html, json, md, po, srt,
+                toml, vtt, xliff, yaml), keeping its structure.
+                By default, it is chosen by the file extension, and
+                "text" translates files as plain text.
+                The languages declared by XLIFF files are used unless
+                -s and -t are given, and -t must match them.
+    --force     translate the messages of catalogs (po, xliff)
+                already translated.
     -h          show summary of options.
+    --include PATTERN,...
+                translate only the values of the keys matching PATTERN
+                in structured files, such as "messages.*".
+    --json      write the translation of each segment (chunk of the
+                text, or segment of a document) as an object of a JSON
+                array, with its source text, the language codes, the
+                detected language, whether it is cached, the time taken
+                in milliseconds, the fuzzy matches in the translation
+                memory and the error if it fails.
+    --jsonl     write the objects of --json as JSON Lines as soon as
+                the segments are translated.
     -l          list the language codes(ISO639-1).
+    --merge-cues
+                merge the cues of subtitles continuing a sentence to
+                translate them together.
+    --no-cache  do not use the translation cache.
+    --no-memory do not use the translation memory.
+    --output-format NAME
+                write each source line (or segment of a document) and
+                its translation together in NAME: "side-by-side" in two
+                columns as wide as the terminal, "md" as a Markdown
+                table, or "html" as an HTML page.
     -s CODE     specify the source language with CODE(ISO639-1).
-    -t CODE     specify the target language with CODE(ISO639-1).
+    -t CODE     specify the target language with CODE(ISO639-1),
+                or "qps" for pseudo-localization.
     -v          output version information.
+
+Translation memory:
+    The translations are stored in the translation memory, and reused
+    for the same texts. The translations of the similar texts are
+    reported before the texts are translated. "memory export" writes
+    it as TMX, and "memory import" adds the units of a TMX file.
+
+Glossary:
+    The terms of the glossaries of config.toml ([[glossary]]) are
+    translated as they are forced to be. "glossary check" reports the
+    lines of TRANSLATION violating them, and exits with 1 if any.
+
+Exit status (batch mode):
+    0           success.
+    1           failure.
+    2           invalid language code in options.
+    3           unsupported language.
+    4           quota of the API exceeded.
+    5           invalid response from the API.
+    6           API unavailable (network or HTTP error).
 `
 	fmt.Fprintf(os.Stderr, msg, version)
 }
@@ -102,7 +190,7 @@ func langCodesToTerm(w io.Writer, substr string) (ok bool) {
 {{end -}}
 └──┴──────────┘ 
 `
-	a := cfg.APIEndpoint.LangListContains(substr)
+	a := tran.LangListContains(cfg.Translator, substr)
 	if len(a) == 0 {
 		return false
 	}
@@ -140,7 +228,7 @@ func commandSource(in, curr string) (source string, ok bool) {
 		if strings.HasPrefix(in, "s ") {
 			in = strings.TrimSpace(string([]rune(in)[2:]))
 		}
-		if code, name, ok = cfg.APIEndpoint.LookupLang(in); !ok {
+		if code, name, ok = tran.LookupLang(cfg.Translator, in); !ok {
 			code, name, ok = tran.LookupPlang(in)
 		}
 		if !ok {
@@ -166,7 +254,7 @@ func commandTarget(in, curr string) (target string, ok bool) {
 		if strings.HasPrefix(in, "t ") {
 			in = strings.TrimSpace(string([]rune(in)[2:]))
 		}
-		if code, name, ok = cfg.APIEndpoint.LookupLang(in); !ok {
+		if code, name, ok = tran.LookupLang(cfg.Translator, in); !ok {
 			code, name, ok = tran.LookupPlang(in)
 		}
 		if !ok {
@@ -202,11 +290,20 @@ func interact(source , target string) {
 	fmt.Fprintf(os.Stderr, "Welcome to the GO-TRAN! (Ver %s)\n", version)
 	helpToTerm()
 	source, target = initialLang(source, target)
+	if memory != nil {
+		memory.OnFuzzy = func(text string, matches []tran.Match) {
+			offerFuzzy(os.Stderr, matches)
+		}
+	}
 
 	line := liner.NewLiner()
 	defer line.Close()
+	var detected string
 	for {
 		pr := fmt.Sprintf("%s:%s> ", source, target)
+		if source == "" && detected != "" {
+			pr = fmt.Sprintf("%s:%s> ", brackets(detected), target)
+		}
 		in, err := line.Prompt(pr)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
@@ -230,6 +327,7 @@ func interact(source , target string) {
 		case in == "s" || strings.HasPrefix(in, "s "):
 			if code, ok := commandSource(in, source); ok {
 				source = code
+				detected = ""
 			}
 		case len(in) <= 2 || strings.HasPrefix(in, "t "):
 			if code, ok := commandTarget(in, target); ok {
@@ -239,11 +337,17 @@ func interact(source , target string) {
 			if out, ok := tran.Ptranslate(in, target); ok {
 				fmt.Fprintln(os.Stderr, cfg.ResultColor.Apply(out))
 			} else {
-				out, err := cfg.APIEndpoint.Translate(in, source, target)
+				ctx := context.Background()
+				r, err := tran.TranslateResult(ctx, cfg.Translator, in, source, target)
 				if err != nil {
 					fmt.Fprintln(os.Stderr, cfg.ErrorColor.Apply(err.Error()))
 				} else {
-					fmt.Fprintln(os.Stderr, cfg.ResultColor.Apply(out))
+					fmt.Fprintln(os.Stderr, cfg.ResultColor.Apply(r.Text))
+					if source == "" {
+						detected = r.Source
+					}
+					saveCache()
+					saveMemory()
 				}
 			}
 		}
@@ -251,60 +355,207 @@ func interact(source , target string) {
 	}
 }
 
-func scanText(sc *bufio.Scanner, limit int) (out string, eof bool) {
-	var sb strings.Builder
-	sb.Grow(4096)
-	for i := 0; i < limit; {
-		if !sc.Scan() {
-			break
-		}
-		s := sc.Text()
-		sb.WriteString(s)
-		sb.WriteString("\n")
-		i += len([]rune(s)) + 1
+// openTranslators wraps cfg.Translator with an Aligner if aligned, the
+// translation memory and the cache, in this order, so that the lines
+// with the markers of the Aligner are neither stored nor cached.
+func openTranslators(aligned, useMemory, useCache bool) {
+	if aligned {
+		cfg.Translator = tran.NewAligner(cfg.Translator, alignMode)
+	}
+	if useMemory {
+		openMemory()
+	}
+	if useCache {
+		openCache()
 	}
-	out = sb.String()
-	return out, len(out) == 0
 }
 
-func translate(r io.Reader, srcEcho bool) error {
+// translate translates the text of r in chunks read by
+// tran.ChunkReader, keeping the spaces between them as they are. The
+// chunks are translated by tran.TranslateStreamFunc as soon as they are
+// read, and written as soon as they and the preceding ones are
+// translated. The lines and their translations are written in
+// outputFormat if any, or interleaved if srcEcho, for which tr must be
+// an Aligner.
+func translate(w io.Writer, r io.Reader, tr tran.Translator, srcEcho bool) error {
 	source := cfg.DefaultSourceCode
 	target := cfg.DefaultTargetCode
-	tran := cfg.APIEndpoint.Translate
-	limit := cfg.APILimitNChars
-	sc := bufio.NewScanner(r)
-	for {
-		in, eof := scanText(sc, limit)
-		if eof {
-			break
-		}
-		out, err := tran(in, source, target)
-		if err != nil {
-			return err
-		}
-		if !srcEcho {
-			fmt.Print(out)
-			continue
+	cr := tran.NewChunkReader(r, cfg.APILimitNChars)
+	first, err := cr.Next()
+	if err == nil && first.Text == "" {
+		// The spaces at the beginning.
+		if !srcEcho && outputFormat == "" {
+			fmt.Fprint(w, first.Sep)
 		}
-		inss := strings.Split(in, "\n")
-		outss := strings.Split(out, "\n")
-		for i, ins := range inss {
-			if i >= len(inss) - 1 && len(ins) == 0 {
-				continue
+		first, err = cr.Next()
+	}
+
+	// ins are the chunks read, whose texts are sent to texts.
+	var mu sync.Mutex
+	var ins []tran.Chunk
+	texts := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	var readErr error
+	go func() {
+		defer close(texts)
+		for c, err := first, err; ; c, err = cr.Next() {
+			if err != nil {
+				if err != io.EOF {
+					readErr = err
+				}
+				return
 			}
-			fmt.Println(ins)
-			var outs string
-			if isTerminal(os.Stdout.Fd()) {
-				outs = cfg.ResultColor.Apply(outss[i])
-			} else {
-				outs = outss[i]
+			mu.Lock()
+			ins = append(ins, c)
+			mu.Unlock()
+			select {
+			case texts <- c.Text:
+			case <-done:
+				return
 			}
-			fmt.Println(outs)
 		}
+	}()
+
+	ctx := context.Background()
+	var detected string
+	var pairs []pair
+	err = tran.TranslateStreamFunc(ctx, tr, texts, source, target, &cfg.APIBatch,
+		func(n int, item *tran.BatchItem) error {
+			if item.Err != nil {
+				return item.Err
+			}
+			r := item.Result
+			mu.Lock()
+			in := ins[n]
+			var prevSep string
+			if n > 0 {
+				prevSep = ins[n-1].Sep
+			}
+			mu.Unlock()
+			out := strings.TrimRightFunc(r.Text, unicode.IsSpace)
+			if outputFormat != "" {
+				if detected == "" {
+					detected = r.Source
+				}
+				if strings.Count(prevSep, "\n") > 1 {
+					// The chunks are separated by blank lines.
+					pairs = append(pairs, pair{})
+				}
+				ps, err := linePairs(in.Text, out)
+				pairs = append(pairs, ps...)
+				return err
+			}
+			if !srcEcho {
+				fmt.Fprint(w, out+in.Sep)
+				return nil
+			}
+			if source == "" && r.Source != "" && r.Source != detected {
+				detected = r.Source
+				msg := "Detected: " + detectedName(detected)
+				if isTerminal(os.Stdout.Fd()) {
+					msg = cfg.StateColor.Apply(msg)
+				}
+				fmt.Fprintln(w, msg)
+			}
+			return echo(w, in.Text, out)
+		})
+	if err == nil {
+		err = readErr
+	}
+	if err != nil || outputFormat == "" {
+		return err
+	}
+	if source == "" {
+		source = detected
+	}
+	return writePairs(w, outputFormat, pairs, source, target)
+}
+
+var errNotAligned = errors.New("translation is not aligned with the source")
+
+// echo writes each line of in followed by the same line of out, which
+// must have the same number of lines.
+func echo(w io.Writer, in, out string) error {
+	pairs, err := linePairs(in, out)
+	if err != nil {
+		return err
+	}
+	for _, p := range pairs {
+		fmt.Fprintln(w, p.source)
+		fmt.Fprintln(w, resultColor(p.translation))
 	}
 	return nil
 }
 
+// resultColor colors the translation s if the output is a terminal.
+func resultColor(s string) string {
+	if isTerminal(os.Stdout.Fd()) {
+		return cfg.ResultColor.Apply(s)
+	}
+	return s
+}
+
+// detectedName returns the name and the code of the detected language.
+func detectedName(code string) string {
+	if _, name, ok := tran.LookupLangCode(code); ok {
+		return name + " " + brackets(code)
+	}
+	return brackets(code)
+}
+
+var errNotDetected = errors.New("language is not detected")
+
+func detect(w io.Writer, r io.Reader, prefix string) error {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	d, ok := tran.Detect(string(buf))
+	if !ok {
+		return errNotDetected
+	}
+	fmt.Fprintf(w, "%s%s\t%s\t%.2f\n", prefix, d.Code, d.Name, d.Confidence)
+	return nil
+}
+
+func detectFile(path, prefix string) error {
+	if isDir(path) {
+		return fmt.Errorf("%s: Is a directory", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := detect(os.Stdout, f, prefix); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// detectBatch prints the language of each file, or of the standard
+// input if no paths are given.
+func detectBatch(paths []string) (err error) {
+	if len(paths) == 0 {
+		if err = detect(os.Stdout, os.Stdin, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+		}
+		return err
+	}
+	for _, path := range paths {
+		prefix := ""
+		if len(paths) > 1 {
+			prefix = path + ":\t"
+		}
+		if e := detectFile(path, prefix); e != nil {
+			fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", e)
+			err = e
+		}
+	}
+	return err
+}
+
 func exists(path string) bool {
 	_, err := os.Stat(path)
 	return !os.IsNotExist(err)
@@ -318,45 +569,250 @@ func isDir(path string) bool {
 	return stat.IsDir()
 }
 
-func batch(paths []string, srcEcho bool) {
-	if len(paths) == 0 {
-		translate(os.Stdin, srcEcho)
-		return
+// Exit codes of the batch mode.
+const (
+	exitOK = iota
+	exitFailure
+	exitUsage
+	exitUnsupportedLanguage
+	exitQuotaExceeded
+	exitInvalidResponse
+	exitUnavailable
+)
+
+func exitCode(err error) int {
+	var ne net.Error
+	var he *tran.HTTPError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, tran.ErrUnsupportedLanguage):
+		return exitUnsupportedLanguage
+	case errors.Is(err, tran.ErrQuotaExceeded):
+		return exitQuotaExceeded
+	case errors.Is(err, tran.ErrInvalidResponse):
+		return exitInvalidResponse
+	case errors.As(err, &ne), errors.As(err, &he):
+		return exitUnavailable
 	}
-	for _, path := range paths {
-		if !exists(path) {
-			fmt.Fprintf(os.Stderr, "GO-TRAN: %s:  No such file or directory\n", path)
-			continue
+	return exitFailure
+}
+
+// documentParser returns the Parser of the document format name, or of
+// the extension of path if name is empty. It returns nil for plain text.
+func documentParser(name, path string) docfmt.Parser {
+	if name == "" {
+		name, _ = docfmt.ByExtension(path)
+	}
+	parse, _ := docfmt.Lookup(name)
+	return parse
+}
+
+// mergeCues and force are set by the options --merge-cues and --force.
+var mergeCues, force bool
+
+// sourceGiven and targetGiven report whether the options -s and -t are
+// given, which take precedence over the languages of documents.
+var sourceGiven, targetGiven bool
+
+// alignMode is set by the option --align.
+var alignMode tran.AlignMode
+
+// include and exclude are the key patterns set by the options --include
+// and --exclude.
+var include, exclude []string
+
+// subcommands are the names of the subcommands.
+var subcommands = []string{"cache", "glossary", "memory"}
+
+// subcommand returns the subcommand named by the first of the arguments
+// rest left by parsing the options of args, or else "". The arguments
+// after "--" are files, even if they are named as a subcommand.
+func subcommand(args, rest []string) string {
+	if len(rest) == 0 {
+		return ""
+	}
+	if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+		return ""
+	}
+	for _, s := range subcommands {
+		if rest[0] == s {
+			return s
 		}
-		if isDir(path) {
-			fmt.Fprintf(os.Stderr, "GO-TRAN: %s: Is a directory\n", path)
-			continue
+	}
+	return ""
+}
+
+// splitPatterns splits the comma-separated patterns s.
+func splitPatterns(s string) []string {
+	var a []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			a = append(a, p)
 		}
-		var f *os.File
-		f, err := os.Open(path)
-		if err != nil {
+	}
+	return a
+}
+
+// translateDocument translates the segments of the document read from
+// r, and writes the document with the translations to w.
+func translateDocument(w io.Writer, r io.Reader, tr tran.Translator, parse docfmt.Parser) error {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	source := cfg.DefaultSourceCode
+	target := cfg.DefaultTargetCode
+	opts := &docfmt.Options{
+		Source:    source,
+		Target:    target,
+		MergeCues: mergeCues,
+		Force:     force,
+		Include:   include,
+		Exclude:   exclude,
+	}
+	doc, err := parse(src, opts)
+	if err != nil {
+		return err
+	}
+	if source, target, err = documentLanguages(doc, source, target); err != nil {
+		return err
+	}
+	// The segments are paired without the Aligner of -e and
+	// --output-format.
+	ctx := tran.WithoutAlignment(context.Background())
+	segs := doc.Segments()
+	outs, err := tran.TranslateBatch(ctx, tr, segs, source, target, &cfg.APIBatch)
+	if err != nil {
+		return err
+	}
+	if outputFormat != "" {
+		pairs := make([]pair, len(segs))
+		for i := range segs {
+			in, err := doc.Unmask(i, segs[i])
+			if err != nil {
+				return err
+			}
+			out, err := doc.Unmask(i, outs[i])
+			if err != nil {
+				return fmt.Errorf("%q: %w", in, err)
+			}
+			pairs[i] = pair{in, out}
+		}
+		return writePairs(w, outputFormat, pairs, source, target)
+	}
+	return doc.Render(w, outs)
+}
+
+// documentLanguages returns the languages declared by doc if it is a
+// LanguageDocument, or else source and target, which are kept if the
+// options -s and -t are given. It fails if the target language declared
+// is not the one of -t.
+func documentLanguages(doc docfmt.Document, source, target string) (string, string, error) {
+	ld, ok := doc.(docfmt.LanguageDocument)
+	if !ok {
+		return source, target, nil
+	}
+	s, t := ld.Languages()
+	if s != "" && !sourceGiven {
+		source = langCode(s)
+	}
+	if t != "" {
+		code := langCode(t)
+		if targetGiven && code != target {
+			return "", "", fmt.Errorf("%s: Target language of the document, but -t %s", t, target)
+		}
+		target = code
+	}
+	return source, target, nil
+}
+
+// langCode returns the language code of a language tag, such as "ja" of
+// "ja-JP", as go-tran knows it.
+func langCode(tag string) string {
+	if code, _, ok := tran.LookupLangCode(tag); ok {
+		return code
+	}
+	if i := strings.IndexAny(tag, "-_"); i > 0 {
+		if code, _, ok := tran.LookupLangCode(tag[:i]); ok {
+			return code
+		}
+	}
+	return strings.ToLower(tag)
+}
+
+func translateInput(w io.Writer, r io.Reader, path string, srcEcho bool, formatName string) error {
+	tr := cfg.Translator
+	if jsonOut != nil {
+		return translateJSON(r, path, tr, documentParser(formatName, path))
+	}
+	if parse := documentParser(formatName, path); parse != nil {
+		return translateDocument(w, r, tr, parse)
+	}
+	return translate(w, r, tr, srcEcho)
+}
+
+func translateFile(path string, srcEcho bool, formatName string) error {
+	if !exists(path) {
+		return fmt.Errorf("%s:  No such file or directory", path)
+	}
+	if isDir(path) {
+		return fmt.Errorf("%s: Is a directory", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := translateInput(os.Stdout, f, path, srcEcho, formatName); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func batch(paths []string, srcEcho bool, formatName string) (err error) {
+	if len(paths) == 0 {
+		if err = translateInput(os.Stdout, os.Stdin, "", srcEcho, formatName); err != nil {
 			fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
-			continue
 		}
-		defer f.Close()
-		translate(f, srcEcho)
+		return err
 	}
-	return
+	for _, path := range paths {
+		if e := translateFile(path, srcEcho, formatName); e != nil {
+			fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", e)
+			err = e
+		}
+	}
+	return err
 }
 
 func main() {
-	var api, srcEcho, help, lang, ver bool
-	var source, target string
+	var api, detectLang, srcEcho, help, lang, ver, noCache, noMemory, jsonArray, jsonLines bool
+	var source, target, formatName, includes, excludes, align string
 
 	flag.Usage	= helpToNonTerm
 	flag.BoolVar(&api, "a", false, "show api (Google Apps Script)")
+	flag.StringVar(&align, "align", "auto", "how to pair the lines for -e")
+	flag.BoolVar(&detectLang, "d", false, "detect the language of the input")
 	flag.BoolVar(&srcEcho, "e", false, "echo the source text")
 	flag.BoolVar(&help, "h", false, "show help")
+	flag.StringVar(&includes, "include", "", "key patterns to translate")
+	flag.BoolVar(&jsonArray, "json", false, "write the translations as JSON")
+	flag.BoolVar(&jsonLines, "jsonl", false, "write the translations as JSON Lines")
 	flag.BoolVar(&lang, "l", false, "list the language codes (ISO-639-1)")
+	flag.StringVar(&excludes, "exclude", "", "key patterns not to translate")
+	flag.BoolVar(&force, "force", false, "translate the translated messages")
+	flag.StringVar(&formatName, "format", "", "document format")
+	flag.BoolVar(&mergeCues, "merge-cues", false, "merge the cues of subtitles")
+	flag.BoolVar(&noCache, "no-cache", false, "do not use the translation cache")
+	flag.BoolVar(&noMemory, "no-memory", false, "do not use the translation memory")
+	flag.StringVar(&outputFormat, "output-format", "", "bilingual output format")
 	flag.StringVar(&source, "s", "", "source language code")
 	flag.StringVar(&target, "t", "", "target language code")
 	flag.BoolVar(&ver, "v", false, "show version")
 	flag.Parse()
+	include, exclude = splitPatterns(includes), splitPatterns(excludes)
+	sourceGiven, targetGiven = source != "", target != ""
 
 	if api {
 		apiScriptToNonTerm()
@@ -374,19 +830,67 @@ func main() {
 		fmt.Fprintf(os.Stderr, "GO-TRAN Version %s\n", version)
 		return
 	}
-
+	if _, ok := docfmt.Lookup(formatName); !ok && formatName != "" && formatName != "text" {
+		fmt.Fprintf(os.Stderr, "GO-TRAN: %s: Unknown format, want: text or %s\n",
+			formatName, strings.Join(docfmt.Names(), ", "))
+		os.Exit(exitUsage)
+	}
+	if outputFormat != "" && !isOutputFormat(outputFormat) {
+		fmt.Fprintf(os.Stderr, "GO-TRAN: %s: Unknown output format, want: %s\n",
+			outputFormat, strings.Join(outputFormats, ", "))
+		os.Exit(exitUsage)
+	}
+	if jsonArray || jsonLines {
+		if (jsonArray && jsonLines) || outputFormat != "" {
+			fmt.Fprintln(os.Stderr, "GO-TRAN: --json, --jsonl and --output-format are exclusive")
+			os.Exit(exitUsage)
+		}
+		jsonOut = &jsonOutput{w: os.Stdout, lines: jsonLines}
+	}
 	var err error
+	if alignMode, err = tran.ParseAlignMode(align); err != nil {
+		fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+		os.Exit(exitUsage)
+	}
+	if detectLang {
+		os.Exit(exitCode(detectBatch(flag.Args())))
+	}
+
 	if cfg, err = config.Load(); err != nil {
 		fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
-		os.Exit(1)
+		os.Exit(exitFailure)
 	}
+	switch subcommand(os.Args[1:], flag.Args()) {
+	case "cache":
+		os.Exit(commandCache(flag.Args()[1:]))
+	case "glossary":
+		if err := cfg.ChangeDefault(source, target); err != nil {
+			fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+			os.Exit(exitUsage)
+		}
+		os.Exit(commandGlossary(flag.Args()[1:]))
+	case "memory":
+		os.Exit(commandMemory(flag.Args()[1:]))
+	}
+	openTranslators((srcEcho || outputFormat != "") && jsonOut == nil,
+		cfg.MemoryEnabled && !noMemory, cfg.CacheEnabled && !noCache)
 	if flag.NArg() == 0 && isTerminal(os.Stdin.Fd()) {
 		interact(source, target)
 		return
 	}
 	if err := cfg.ChangeDefault(source, target); err != nil {
 		fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
-		return
+		os.Exit(exitUsage)
+	}
+	err = batch(flag.Args(), srcEcho, formatName)
+	if jsonOut != nil {
+		if e := jsonOut.flush(); e != nil && err == nil {
+			err = e
+		}
+	}
+	saveCache()
+	saveMemory()
+	if err != nil {
+		os.Exit(exitCode(err))
 	}
-	batch(flag.Args(), srcEcho)
 }
@@ -1,61 +1,630 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/y-bash/go-tran"
+	"github.com/y-bash/go-tran/config"
+	docfmt "github.com/y-bash/go-tran/format"
 )
 
-type ScanTextTest struct {
+type EchoTest struct {
+	in  string
+	out string
+	w   string
+	err bool
+}
+
+var echotests = []EchoTest{
+	0: {"abc", "ABC", "abc\nABC\n", false},
+	1: {"abc\n\ndef", "ABC\n\nDEF", "abc\nABC\n\n\ndef\nDEF\n", false},
+	2: {"abc\ndef", "ABC DEF", "", true},
+}
+
+func TestEcho(t *testing.T) {
+	cfg = &config.Config{}
+	for i, tt := range echotests {
+		var buf bytes.Buffer
+		err := echo(&buf, tt.in, tt.out)
+		if (err != nil) != tt.err {
+			t.Errorf("#%d echo(%q, %q) have error: %v, want error: %v", i, tt.in, tt.out, err, tt.err)
+			continue
+		}
+		if buf.String() != tt.w {
+			t.Errorf("#%d echo(%q, %q) wrote %q, want: %q", i, tt.in, tt.out, buf.String(), tt.w)
+		}
+	}
+}
+
+type upperTranslator struct{}
+
+func (t upperTranslator) Translate(text, source, target string) (string, error) {
+	return t.TranslateContext(context.Background(), text, source, target)
+}
+
+func (upperTranslator) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	return strings.ToUpper(text), nil
+}
+
+func (upperTranslator) Languages() (tran.ISO639List, error) {
+	return tran.AllLangList(), nil
+}
+
+func (upperTranslator) Detect(text string) (string, error) {
+	return "", tran.ErrNotSupported
+}
+
+type TranslateTest struct {
+	in      string
+	srcEcho bool
+	out     string
+}
+
+var translatetests = []TranslateTest{
+	0: {"", false, ""},
+	1: {"abc\ndef\n", false, "ABC\nDEF\n"},
+	2: {"abc\ndef", true, "abc\nABC\ndef\nDEF\n"},
+	3: {"abc\ndef", false, "ABC\nDEF"},
+	4: {"\n ab.\n\ncd. ef.\n", false, "\n AB.\n\nCD. EF.\n"},
+	5: {"ab cd ef.\n", false, "AB CD EF.\n"},
+	6: {"ab cd ef.\n", true, "ab\nAB\ncd\nCD\nef.\nEF.\n"},
+}
+
+func TestTranslate(t *testing.T) {
+	cfg = &config.Config{APILimitNChars: 4}
+	for i, tt := range translatetests {
+		var buf bytes.Buffer
+		err := translate(&buf, strings.NewReader(tt.in), upperTranslator{}, tt.srcEcho)
+		if err != nil {
+			t.Errorf("#%d have error: %s, want error: nil", i, err)
+			continue
+		}
+		if buf.String() != tt.out {
+			t.Errorf("#%d translate(%q, %v) = %q, want: %q",
+				i, tt.in, tt.srcEcho, buf.String(), tt.out)
+		}
+	}
+}
+
+func TestTranslate_Stream(t *testing.T) {
+	cfg = &config.Config{APILimitNChars: 10}
+	in, inw := io.Pipe()
+	out, outw := io.Pipe()
+	go func() {
+		outw.CloseWithError(translate(outw, in, upperTranslator{}, false))
+	}()
+	inw.Write([]byte("One. Two.\nThree. Four.\n"))
+	buf := make([]byte, 10)
+	if _, err := io.ReadFull(out, buf); err != nil || string(buf) != "ONE. TWO.\n" {
+		t.Errorf("translate() wrote %q before the end of the input, want: %q", buf, "ONE. TWO.\n")
+	}
+	inw.Close()
+	if rest, err := ioutil.ReadAll(out); err != nil || string(rest) != "THREE. FOUR.\n" {
+		t.Errorf("translate() wrote %q at the end, want: %q", rest, "THREE. FOUR.\n")
+	}
+}
+
+// mergeTranslator is an upperTranslator which merges the lines.
+type mergeTranslator struct {
+	upperTranslator
+}
+
+func (t mergeTranslator) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	return strings.Replace(strings.ToUpper(text), "\n", " ", -1), nil
+}
+
+func TestTranslate_Aligned(t *testing.T) {
+	cfg = &config.Config{APILimitNChars: 100}
+	for i, mode := range []tran.AlignMode{tran.AlignAuto, tran.AlignLines} {
+		var buf bytes.Buffer
+		tr := tran.NewAligner(mergeTranslator{}, mode)
+		err := translate(&buf, strings.NewReader("abc\ndef\n\nghi\n"), tr, true)
+		if want := "abc\nABC\ndef\nDEF\n\n\nghi\nGHI\n"; err != nil || buf.String() != want {
+			t.Errorf("#%d translate() = (%q, %v), want: (%q, nil)", i, buf.String(), err, want)
+		}
+	}
+}
+
+func TestOpenTranslators_Aligned(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-tran")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cfg = &config.Config{APILimitNChars: 100, DefaultSourceCode: "en", DefaultTargetCode: "ja", Translator: mergeTranslator{},
+		MemoryPath: filepath.Join(dir, "memory.tmx"), MemoryOptions: tran.DefaultMemoryOptions}
+	defer func() { memory = nil }()
+	openTranslators(true, true, false)
+	var buf bytes.Buffer
+	if err := translate(&buf, strings.NewReader("Hello {name}\nGood bye\n"), cfg.Translator, true); err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := memory.Export(&sb); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(sb.String(), "⟦") || !strings.Contains(sb.String(), "HELLO {NAME}&#xA;GOOD BYE") {
+		t.Errorf("memory exported:\n%s\nwant: the lines without markers", sb.String())
+	}
+}
+
+// detectTranslator is an upperTranslator which detects English for
+// the texts beginning with "en:" and Japanese for the others.
+type detectTranslator struct {
+	upperTranslator
+}
+
+func (t detectTranslator) TranslateResult(ctx context.Context, text, source, target string) (*tran.Result, error) {
+	out, _ := t.TranslateContext(ctx, text, source, target)
+	if strings.HasPrefix(text, "en:") {
+		return &tran.Result{Text: out, Source: "en"}, nil
+	}
+	return &tran.Result{Text: out, Source: "ja"}, nil
+}
+
+var translatedetectedtests = []TranslateTest{
+	0: {"abc\ndef\n", false, "ABC\nDEF\n"},
+	1: {"abc\ndef\n", true, "Detected: Japanese (ja)\nabc\nABC\ndef\nDEF\n"},
+	2: {"en:a\nen:b\nc\n", true,
+		"Detected: English (en)\nen:a\nEN:A\nen:b\nEN:B\nDetected: Japanese (ja)\nc\nC\n"},
+}
+
+func TestTranslate_Detected(t *testing.T) {
+	cfg = &config.Config{APILimitNChars: 4}
+	for i, tt := range translatedetectedtests {
+		var buf bytes.Buffer
+		err := translate(&buf, strings.NewReader(tt.in), detectTranslator{}, tt.srcEcho)
+		if err != nil {
+			t.Errorf("#%d have error: %s, want error: nil", i, err)
+			continue
+		}
+		if buf.String() != tt.out {
+			t.Errorf("#%d translate(%q, %v) = %q, want: %q",
+				i, tt.in, tt.srcEcho, buf.String(), tt.out)
+		}
+	}
+}
+
+func TestTranslate_Pseudo(t *testing.T) {
+	tr := tran.NewProtector(tran.NewPseudoRouter(upperTranslator{}))
+	cfg = &config.Config{APILimitNChars: 4, DefaultTargetCode: "ja"}
+	var buf bytes.Buffer
+	if err := translate(&buf, strings.NewReader("abc\n"), tr, false); err != nil || buf.String() != "ABC\n" {
+		t.Errorf("translate(%q) to ja = (%q, %v), want: (%q, nil)", "abc\n", buf.String(), err, "ABC\n")
+	}
+	cfg.DefaultTargetCode = tran.PseudoTarget
+	buf.Reset()
+	if err := translate(&buf, strings.NewReader("abc\n"), tr, false); err != nil || buf.String() != "[àƀç ~]\n" {
+		t.Errorf("translate(%q) to qps = (%q, %v), want: (%q, nil)", "abc\n", buf.String(), err, "[àƀç ~]\n")
+	}
+}
+
+type TranslateInputTest struct {
+	in         string
+	path       string
+	formatName string
+	out        string
+}
+
+var translateinputtests = []TranslateInputTest{
+	0: {"# abc\n\n```\ncode\n```\n", "a.md", "", "# ABC\n\n```\ncode\n```\n"},
+	1: {"# abc\n", "a.md", "text", "# ABC\n"},
+	2: {"# abc\n`x`\n", "", "md", "# ABC\n`x`\n"},
+	3: {"`x` y\n", "a.txt", "", "`X` Y\n"},
+	4: {"{\"a\": \"b\", \"n\": 1}\n", "en.json", "", "{\"a\": \"B\", \"n\": 1}\n"},
+}
+
+func TestTranslateInput(t *testing.T) {
+	cfg = &config.Config{APILimitNChars: 4, Translator: upperTranslator{}}
+	for i, tt := range translateinputtests {
+		var buf bytes.Buffer
+		err := translateInput(&buf, strings.NewReader(tt.in), tt.path, false, tt.formatName)
+		if err != nil {
+			t.Errorf("#%d have error: %s, want error: nil", i, err)
+			continue
+		}
+		if buf.String() != tt.out {
+			t.Errorf("#%d translateInput(%q, %q, %q) = %q, want: %q",
+				i, tt.in, tt.path, tt.formatName, buf.String(), tt.out)
+		}
+	}
+}
+
+// langTranslator translates texts into their languages and themselves.
+type langTranslator struct {
+	upperTranslator
+}
+
+func (t langTranslator) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	return source + ">" + target + ":" + text, nil
+}
+
+type DocumentLanguagesTest struct {
+	source, target string // given by -s and -t if not empty
+	out            string
+	err            bool
+}
+
+var documentlanguagestests = []DocumentLanguagesTest{
+	0: {"", "", "<target state=\"needs-review-translation\">en&gt;ja:Hi</target>", false},
+	1: {"", "ja", "<target state=\"needs-review-translation\">en&gt;ja:Hi</target>", false},
+	2: {"fr", "", "<target state=\"needs-review-translation\">fr&gt;ja:Hi</target>", false},
+	3: {"", "de", "", true},
+}
+
+func TestTranslateInput_DocumentLanguages(t *testing.T) {
+	defer func() { sourceGiven, targetGiven = false, false }()
+	const in = `<xliff version="1.2"><file source-language="en-US" target-language="ja-JP" datatype="plaintext">` +
+		`<body><trans-unit id="1"><source>Hi</source></trans-unit></body></file></xliff>`
+	for i, tt := range documentlanguagestests {
+		cfg = &config.Config{APILimitNChars: 100, DefaultTargetCode: "en", Translator: langTranslator{}}
+		if err := cfg.ChangeDefault(tt.source, tt.target); err != nil {
+			t.Fatal(err)
+		}
+		sourceGiven, targetGiven = tt.source != "", tt.target != ""
+		var buf bytes.Buffer
+		err := translateInput(&buf, strings.NewReader(in), "a.xlf", false, "")
+		if tt.err {
+			if err == nil {
+				t.Errorf("#%d have error: nil, want: error", i)
+			}
+			continue
+		}
+		if err != nil || !strings.Contains(buf.String(), tt.out) {
+			t.Errorf("#%d translateInput() = (%q, %v), want: %q in it", i, buf.String(), err, tt.out)
+		}
+	}
+}
+
+type SplitPatternsTest struct {
+	in  string
+	out []string
+}
+
+var splitpatternstests = []SplitPatternsTest{
+	0: {"", nil},
+	1: {"a.*", []string{"a.*"}},
+	2: {" a , b.c,, ", []string{"a", "b.c"}},
+}
+
+func TestSplitPatterns(t *testing.T) {
+	for i, tt := range splitpatternstests {
+		out := splitPatterns(tt.in)
+		if fmt.Sprintf("%q", out) != fmt.Sprintf("%q", tt.out) {
+			t.Errorf("#%d splitPatterns(%q) = %q, want: %q", i, tt.in, out, tt.out)
+		}
+	}
+}
+
+type SubcommandTest struct {
+	args []string
+	rest []string
+	out  string
+}
+
+var subcommandtests = []SubcommandTest{
+	0: {nil, nil, ""},
+	1: {[]string{"cache", "stats"}, []string{"cache", "stats"}, "cache"},
+	2: {[]string{"-s", "en", "glossary", "check"}, []string{"glossary", "check"}, "glossary"},
+	3: {[]string{"--", "memory"}, []string{"memory"}, ""},
+	4: {[]string{"-e", "--", "cache", "memory"}, []string{"cache", "memory"}, ""},
+	5: {[]string{"a.txt", "memory"}, []string{"a.txt", "memory"}, ""},
+	6: {[]string{"memory", "--"}, []string{"memory", "--"}, "memory"},
+}
+
+func TestSubcommand(t *testing.T) {
+	for i, tt := range subcommandtests {
+		if out := subcommand(tt.args, tt.rest); out != tt.out {
+			t.Errorf("#%d subcommand(%q, %q) = %q, want: %q", i, tt.args, tt.rest, out, tt.out)
+		}
+	}
+}
+
+type DetectTest struct {
+	in     string
+	prefix string
+	out    string
+	err    error
+}
+
+var detecttests = []DetectTest{
+	0: {"猫はテーブルの上で寝ています。\n", "", "ja\tJapanese\t1.00\n", nil},
+	1: {"Кошка спит на столе.\n", "a.txt:\t", "a.txt:\tru\tRussian\t", nil},
+	2: {"123\n", "", "", errNotDetected},
+}
+
+func TestDetect(t *testing.T) {
+	for i, tt := range detecttests {
+		var buf bytes.Buffer
+		err := detect(&buf, strings.NewReader(tt.in), tt.prefix)
+		if err != tt.err {
+			t.Errorf("#%d have error: %v, want error: %v", i, err, tt.err)
+			continue
+		}
+		if !strings.HasPrefix(buf.String(), tt.out) {
+			t.Errorf("#%d detect(%q) = %q, want: %q...", i, tt.in, buf.String(), tt.out)
+		}
+	}
+}
+
+type ExitCodeTest struct {
+	err  error
+	code int
+}
+
+var exitcodetests = []ExitCodeTest{
+	0: {nil, exitOK},
+	1: {errors.New("x: Is a directory"), exitFailure},
+	2: {fmt.Errorf("a.txt: %w", &tran.APIError{Code: 400, Message: "Invalid argument: target"}), exitUnsupportedLanguage},
+	3: {&tran.APIError{Code: 400, Message: "Service invoked too many times in a short time"}, exitQuotaExceeded},
+	4: {&tran.HTTPError{StatusCode: 429}, exitQuotaExceeded},
+	5: {fmt.Errorf("%w: unexpected EOF", tran.ErrInvalidResponse), exitInvalidResponse},
+	6: {&url.Error{Op: "Post", URL: "http://x", Err: &net.DNSError{}}, exitUnavailable},
+	7: {&tran.HTTPError{StatusCode: 503}, exitUnavailable},
+}
+
+func TestExitCode(t *testing.T) {
+	for i, tt := range exitcodetests {
+		if code := exitCode(tt.err); code != tt.code {
+			t.Errorf("#%d exitCode(%v) = %d, want: %d", i, tt.err, code, tt.code)
+		}
+	}
+}
+
+type CheckGlossaryTest struct {
+	source      string
+	translation string
+	out         string
+	n           int
+	err         bool
+}
+
+var checkglossarytests = []CheckGlossaryTest{
+	0: {"", "", "", 0, false},
+	1: {"a widget\nGO-TRAN\n", "ウィジェット\nGO-TRAN\n", "", 0, false},
+	2: {"a widget\nGO-TRAN\n", "部品\nゴートラン\n",
+		"ja.txt:1: \"widget\" is not translated as \"ウィジェット\"\n" +
+			"ja.txt:2: \"GO-TRAN\" is translated, want: kept as it is\n", 2, false},
+	3: {"a\nb\n", "a\n", "", 0, true},
+}
+
+func TestCheckGlossary(t *testing.T) {
+	gs := []*tran.Glossary{{Source: "en", Target: "ja",
+		Terms: []tran.Term{{Source: "widget", Target: "ウィジェット"}, {Source: "GO-TRAN"}}}}
+	for i, tt := range checkglossarytests {
+		var sb strings.Builder
+		n, err := checkGlossary(&sb, gs, strings.NewReader(tt.source), strings.NewReader(tt.translation), "ja.txt")
+		if (err != nil) != tt.err {
+			t.Errorf("#%d have error: %v, want error: %v", i, err, tt.err)
+			continue
+		}
+		if n != tt.n || sb.String() != tt.out {
+			t.Errorf("#%d have: (%d, %q), want: (%d, %q)", i, n, sb.String(), tt.n, tt.out)
+		}
+	}
+}
+
+func TestFuzzyTo(t *testing.T) {
+	var sb strings.Builder
+	fuzzyTo(&sb, "the cats", []tran.Match{
+		{Entry: tran.MemoryEntry{Text: "the cat", Translated: "猫"}, Score: 0.875},
+		{Entry: tran.MemoryEntry{Text: "the bats", Translated: "コウモリ"}, Score: 0.75},
+	})
+	want := `GO-TRAN: "the cats" is similar to the translated:
+     88% "the cat"
+         "猫"
+     75% "the bats"
+         "コウモリ"
+`
+	if sb.String() != want {
+		t.Errorf("fuzzyTo() wrote:\n%s\nwant:\n%s", sb.String(), want)
+	}
+}
+
+type WrapTest struct {
 	in    string
-	limit int
+	width int
 	out   []string
 }
 
-var scantexttests = []ScanTextTest{
-	0: {"", 1, []string{}},
-	1: {"123\n456\n789", 1, []string{"123\n", "456\n", "789\n"}},
-	2: {"123\n456\n789", 2, []string{"123\n", "456\n", "789\n"}},
-	3: {"123\n456\n789", 3, []string{"123\n", "456\n", "789\n"}},
-	4: {"123\n456\n789", 4, []string{"123\n", "456\n", "789\n"}},
-	5: {"123\n456\n789", 5, []string{"123\n456\n", "789\n"}},
-	6: {"123\n456\n789", 6, []string{"123\n456\n", "789\n"}},
-	7: {"123\n456\n789", 7, []string{"123\n456\n", "789\n"}},
-	8: {"123\n456\n789", 8, []string{"123\n456\n", "789\n"}},
-	9: {"123\n456\n789", 9, []string{"123\n456\n789\n"}},
-	10: {"123\n456\n789", 20, []string{"123\n456\n789\n"}},
-}
-
-func format(in []string) string {
-	out := []string{}
-	for i, s := range in {
-		out = append(out, fmt.Sprintf("%d: %q", i, s))
-	}
-	return "[" + strings.Join(out, ", ") + "]"
-}
-
-func TestScanText(t *testing.T) {
-	for i, tt := range scantexttests {
-		r := strings.NewReader(tt.in)
-		sc := bufio.NewScanner(r)
-		out := []string{}
-		for {
-			s, eof := scanText(sc, tt.limit)
-			if eof {
-				break
-			}
-			out = append(out, s)
+var wraptests = []WrapTest{
+	0: {"", 10, []string{""}},
+	1: {"the quick brown fox", 10, []string{"the quick", "brown fox"}},
+	2: {"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+	3: {"猫はテーブルの上", 6, []string{"猫はテ", "ーブル", "の上"}},
+	4: {"ab 猫は", 5, []string{"ab 猫", "は"}},
+	5: {"abc\n\ndef  ", 10, []string{"abc", "", "def"}},
+}
+
+func TestWrap(t *testing.T) {
+	for i, tt := range wraptests {
+		out := wrap(tt.in, tt.width)
+		if fmt.Sprintf("%q", out) != fmt.Sprintf("%q", tt.out) {
+			t.Errorf("#%d wrap(%q, %d) = %q, want: %q", i, tt.in, tt.width, out, tt.out)
 		}
-		if len(out) != len(tt.out) {
-			t.Errorf("#%d len(out) = \nhave: %d %s, \nwant: %d %s",
-				i, len(out), format(out), len(tt.out), format(tt.out))
+	}
+}
+
+func TestWriteSideBySide(t *testing.T) {
+	cfg = &config.Config{}
+	var sb strings.Builder
+	writeSideBySide(&sb, []pair{{"the cat", "猫"}, {}, {"sleeps on the table", "テーブルの上で寝る"}}, 23)
+	want := "the cat    | 猫\n" +
+		"\n" +
+		"sleeps on  | テーブルの\n" +
+		"the table  | 上で寝る\n"
+	if sb.String() != want {
+		t.Errorf("writeSideBySide() wrote:\n%s\nwant:\n%s", sb.String(), want)
+	}
+}
+
+type OutputFormatTest struct {
+	format string
+	out    string
+}
+
+var outputformattests = []OutputFormatTest{
+	0: {"md", "| English (en) | Japanese (ja) |\n| --- | --- |\n| a\\|b | A\\|B |\n| cd | CD |\n| <e> | <E> |\n"},
+	1: {"html", "<tr><th>English (en)</th><th>Japanese (ja)</th></tr>\n</thead>\n" +
+		"<tbody>\n" +
+		"<tr><td lang=\"en\">a|b</td><td lang=\"ja\">A|B</td></tr>\n" +
+		"<tr><td lang=\"en\">cd</td><td lang=\"ja\">CD</td></tr>\n" +
+		"</tbody>\n<tbody>\n" +
+		"<tr><td lang=\"en\">&lt;e&gt;</td><td lang=\"ja\">&lt;E&gt;</td></tr>\n" +
+		"</tbody>\n</table>\n</body>\n</html>\n"},
+	2: {"side-by-side", "a|b        | A|B\ncd         | CD\n\n<e>        | <E>\n"},
+}
+
+func TestTranslate_OutputFormat(t *testing.T) {
+	cfg = &config.Config{APILimitNChars: 6, DefaultSourceCode: "en", DefaultTargetCode: "ja"}
+	defer func() { outputFormat = "" }()
+	os.Setenv("COLUMNS", "23")
+	defer os.Unsetenv("COLUMNS")
+	for i, tt := range outputformattests {
+		outputFormat = tt.format
+		var buf bytes.Buffer
+		err := translate(&buf, strings.NewReader("a|b\ncd\n\n<e>\n"), upperTranslator{}, false)
+		if err != nil {
+			t.Errorf("#%d have error: %s, want error: nil", i, err)
+			continue
+		}
+		if !strings.HasSuffix(buf.String(), tt.out) {
+			t.Errorf("#%d translate() with %s =\n%s\nwant suffix:\n%s", i, tt.format, buf.String(), tt.out)
+		}
+	}
+}
+
+func TestTranslateDocument_Aligner(t *testing.T) {
+	cfg = &config.Config{APILimitNChars: 100}
+	var buf bytes.Buffer
+	tr := tran.NewAligner(upperTranslator{}, tran.AlignLines)
+	err := translateDocument(&buf, strings.NewReader("a  b\nc\n"), tr, docfmt.ParseMarkdown)
+	if want := "A  B C\n"; err != nil || buf.String() != want {
+		t.Errorf("translateDocument() = (%q, %v), want: (%q, nil)", buf.String(), err, want)
+	}
+}
+
+func TestTranslateDocument_OutputFormat(t *testing.T) {
+	cfg = &config.Config{APILimitNChars: 100, DefaultSourceCode: "en", DefaultTargetCode: "ja"}
+	defer func() { outputFormat = "" }()
+	outputFormat = "md"
+	var buf bytes.Buffer
+	err := translateDocument(&buf, strings.NewReader("Use `go` and [the docs](http://x) now.\n"), upperTranslator{}, docfmt.ParseMarkdown)
+	want := "| Use `go` and [the docs](http://x) now. | USE `go` AND [THE DOCS](http://x) NOW. |\n"
+	if err != nil || !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("translateDocument() = (%q, %v), want suffix: %q", buf.String(), err, want)
+	}
+}
+
+// failTranslator is an upperTranslator which fails for "fail".
+type failTranslator struct {
+	upperTranslator
+}
+
+func (t failTranslator) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	if text == "fail" {
+		return "", errors.New("failed")
+	}
+	if strings.HasPrefix(text, "drop ") {
+		return "DROPPED", nil
+	}
+	return strings.ToUpper(text), nil
+}
+
+type TranslateJSONTest struct {
+	in    string
+	path  string
+	lines bool
+	segs  []segmentJSON
+	err   bool
+}
+
+var translatejsontests = []TranslateJSONTest{
+	0: {"", "", false, []segmentJSON{}, false},
+	1: {"abc\n\nfail\nxy\n", "a.txt", true, []segmentJSON{
+		{File: "a.txt", Index: 0, Text: "abc", Translated: "ABC", Target: "ja"},
+		{File: "a.txt", Index: 1, Text: "fail", Target: "ja", Error: "failed"},
+		{File: "a.txt", Index: 2, Text: "xy", Translated: "XY", Target: "ja"},
+	}, true},
+	2: {"{\"a\": \"b\", \"n\": 1}\n", "en.json", false, []segmentJSON{
+		{File: "en.json", Index: 0, Text: "b", Translated: "B", Target: "ja"},
+	}, false},
+	3: {"Use `go` now.\n\ndrop `x`\n", "a.md", true, []segmentJSON{
+		{File: "a.md", Index: 0, Text: "Use `go` now.", Translated: "USE `go` NOW.", Target: "ja"},
+		{File: "a.md", Index: 1, Text: "drop `x`", Target: "ja", Error: "placeholders lost in translation: \"`x`\""},
+	}, true},
+}
+
+func TestTranslateInput_JSON(t *testing.T) {
+	cfg = &config.Config{APILimitNChars: 4, DefaultTargetCode: "ja", Translator: failTranslator{}}
+	defer func() { jsonOut = nil }()
+	for i, tt := range translatejsontests {
+		var buf bytes.Buffer
+		jsonOut = &jsonOutput{w: &buf, lines: tt.lines}
+		err := translateInput(&buf, strings.NewReader(tt.in), tt.path, false, "")
+		if (err != nil) != tt.err {
+			t.Errorf("#%d have error: %v, want error: %v", i, err, tt.err)
 			continue
 		}
-		for j, s := range out {
-			if s != tt.out[j] {
-				t.Errorf("#%d [%d] have: %s, want: %s", i, j, s, tt.out[j])
+		if err := jsonOut.flush(); err != nil {
+			t.Fatal(err)
+		}
+		var segs []segmentJSON
+		if tt.lines {
+			if n := strings.Count(buf.String(), "\n"); n != len(tt.segs) {
+				t.Errorf("#%d have lines: %d, want: %d", i, n, len(tt.segs))
+			}
+			dec := json.NewDecoder(&buf)
+			for dec.More() {
+				var s segmentJSON
+				if err := dec.Decode(&s); err != nil {
+					t.Fatal(err)
+				}
+				segs = append(segs, s)
+			}
+		} else if err := json.Unmarshal(buf.Bytes(), &segs); err != nil {
+			t.Fatalf("#%d have error: %s, want: JSON array", i, err)
+		}
+		for j := range segs {
+			if segs[j].ElapsedMS < 0 {
+				t.Errorf("#%d.%d have elapsed_ms: %g, want: >= 0", i, j, segs[j].ElapsedMS)
 			}
+			segs[j].ElapsedMS = 0
+		}
+		if fmt.Sprintf("%+v", segs) != fmt.Sprintf("%+v", tt.segs) {
+			t.Errorf("#%d have:\n\t%+v,\nwant:\n\t%+v", i, segs, tt.segs)
 		}
 	}
 }
+
+func TestTranslateInput_JSONFuzzy(t *testing.T) {
+	m, err := tran.OpenMemory(failTranslator{}, filepath.Join("testdata", "notexists.tmx"),
+		&tran.MemoryOptions{Threshold: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Store("abcd", "en", "ja", "ABCD")
+	cfg = &config.Config{APILimitNChars: 4, DefaultSourceCode: "en", DefaultTargetCode: "ja", Translator: m}
+	var buf bytes.Buffer
+	jsonOut = &jsonOutput{w: &buf, lines: true}
+	defer func() { jsonOut = nil }()
+	if err := translateInput(&buf, strings.NewReader("abce\nabcd\n"), "", false, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := `"fuzzy":[{"text":"abcd","translated":"ABCD","source":"en","score":0.75}]`
+	if strings.Count(buf.String(), want) != 1 {
+		t.Errorf("have:\n%s\nwant a segment with: %s", buf.String(), want)
+	}
+}
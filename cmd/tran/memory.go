@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/y-bash/go-tran"
+)
+
+var memory *tran.Memory
+
+func openMemory() {
+	m, err := tran.OpenMemory(cfg.Translator, cfg.MemoryPath, &cfg.MemoryOptions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "GO-TRAN: translation memory is disabled: %s\n", err)
+		return
+	}
+	memory = m
+	if jsonOut == nil {
+		// They are written in the segments of --json and --jsonl.
+		memory.OnFuzzy = func(text string, matches []tran.Match) {
+			fuzzyTo(os.Stderr, text, matches)
+		}
+	}
+	cfg.Translator = m
+}
+
+func saveMemory() {
+	if memory == nil {
+		return
+	}
+	if err := memory.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+	}
+}
+
+// fuzzyTo writes the fuzzy matches of text in the translation memory,
+// which are reported before text is translated. They are written at
+// once, since the texts may be translated in parallel.
+func fuzzyTo(w io.Writer, text string, matches []tran.Match) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "GO-TRAN: %q is similar to the translated:\n", text)
+	for _, m := range matches {
+		fmt.Fprintf(&sb, "    %3.0f%% %q\n         %q\n", m.Score*100, m.Entry.Text, m.Entry.Translated)
+	}
+	io.WriteString(w, sb.String())
+}
+
+// offerFuzzy writes the fuzzy matches of a text in the REPL, which are
+// offered before the text is translated.
+func offerFuzzy(w io.Writer, matches []tran.Match) {
+	for _, m := range matches {
+		fmt.Fprintln(w, cfg.InfoColor.Apply(fmt.Sprintf("Memory %3.0f%%: %s", m.Score*100, m.Entry.Text)))
+		fmt.Fprintln(w, cfg.InfoColor.Apply(fmt.Sprintf("             %s", m.Entry.Translated)))
+	}
+}
+
+func commandMemory(args []string) int {
+	if len(args) == 0 || args[0] != "import" && len(args) != 1 || args[0] == "import" && len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage:  tran memory stats|clear|export|import FILE")
+		return exitUsage
+	}
+	m, err := tran.OpenMemory(cfg.Translator, cfg.MemoryPath, &cfg.MemoryOptions)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+		return exitFailure
+	}
+	switch args[0] {
+	case "stats":
+		fmt.Fprintf(os.Stdout, "Path:     %s\n", cfg.MemoryPath)
+		fmt.Fprintf(os.Stdout, "Entries:  %d\n", m.Len())
+	case "clear":
+		m.Clear()
+	case "export":
+		if err := m.Export(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+			return exitFailure
+		}
+		return exitOK
+	case "import":
+		f, err := os.Open(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+			return exitFailure
+		}
+		defer f.Close()
+		n, err := m.Import(f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "GO-TRAN: %s: %s\n", args[1], err)
+			return exitFailure
+		}
+		fmt.Fprintf(os.Stdout, "Imported: %d entries\n", n)
+	default:
+		fmt.Fprintf(os.Stderr, "GO-TRAN: %s: Unknown memory command\n", args[0])
+		return exitUsage
+	}
+	if err := m.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "GO-TRAN: %s\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// outputFormats are the names of the bilingual output formats of the
+// option --output-format.
+var outputFormats = []string{"side-by-side", "md", "html"}
+
+// outputFormat is set by the option --output-format, which is empty for
+// the translation only, or the interleaved output of -e.
+var outputFormat string
+
+// isOutputFormat reports whether name is one of outputFormats.
+func isOutputFormat(name string) bool {
+	for _, s := range outputFormats {
+		if name == s {
+			return true
+		}
+	}
+	return false
+}
+
+// pair is a segment of the source text and its translation.
+type pair struct {
+	source      string
+	translation string
+}
+
+// linePairs pairs each line of in with the same line of out, which must
+// have the same number of lines.
+func linePairs(in, out string) ([]pair, error) {
+	ins := strings.Split(in, "\n")
+	outs := strings.Split(out, "\n")
+	if len(ins) != len(outs) {
+		return nil, fmt.Errorf("%w: %d lines, %d translated", errNotAligned, len(ins), len(outs))
+	}
+	pairs := make([]pair, len(ins))
+	for i := range ins {
+		pairs[i] = pair{ins[i], outs[i]}
+	}
+	return pairs, nil
+}
+
+// writePairs writes pairs in the output format name. The empty pairs
+// separate paragraphs.
+func writePairs(w io.Writer, name string, pairs []pair, source, target string) error {
+	switch name {
+	case "side-by-side":
+		writeSideBySide(w, pairs, outputWidth())
+	case "md":
+		writeMarkdownTable(w, pairs, source, target)
+	case "html":
+		writeHTMLPage(w, pairs, source, target)
+	default:
+		return fmt.Errorf("%s: Unknown output format, want: %s", name, strings.Join(outputFormats, ", "))
+	}
+	return nil
+}
+
+// outputWidth returns the width of the terminal of the standard output,
+// or else $COLUMNS or 80.
+func outputWidth() int {
+	if n, ok := terminalWidth(os.Stdout.Fd()); ok {
+		return n
+	}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
+
+// columnSep separates the columns of the side-by-side output.
+const columnSep = " | "
+
+// writeSideBySide writes the source texts and the translations of pairs
+// in two columns, which are width wide together. The texts are wrapped
+// to their columns.
+func writeSideBySide(w io.Writer, pairs []pair, width int) {
+	col := (width - len(columnSep)) / 2
+	if col < 4 {
+		col = 4
+	}
+	for _, p := range pairs {
+		if p.source == "" && p.translation == "" {
+			fmt.Fprintln(w)
+			continue
+		}
+		left := wrap(p.source, col)
+		right := wrap(p.translation, col)
+		for i := 0; i < len(left) || i < len(right); i++ {
+			var l, r string
+			if i < len(left) {
+				l = left[i]
+			}
+			if i < len(right) {
+				r = resultColor(right[i])
+			}
+			line := runewidth.FillRight(l, col) + columnSep + r
+			fmt.Fprintln(w, strings.TrimRight(line, " "))
+		}
+	}
+}
+
+// wrap wraps the lines of s to width, where the double-width characters
+// take two columns. The lines break at spaces or next to double-width
+// characters, or anywhere if a word is too long.
+func wrap(s string, width int) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		rs := []rune(strings.TrimRightFunc(line, unicode.IsSpace))
+		for {
+			// n is the number of the runes fitting in width.
+			n, sum := 0, 0
+			for ; n < len(rs); n++ {
+				if sum += runewidth.RuneWidth(rs[n]); sum > width {
+					break
+				}
+			}
+			if n == len(rs) {
+				lines = append(lines, string(rs))
+				break
+			}
+			cut := n
+			for ; cut > 0 && !breakable(rs, cut); cut-- {
+			}
+			if cut == 0 {
+				cut = n
+				if cut == 0 {
+					cut = 1
+				}
+			}
+			lines = append(lines, strings.TrimRightFunc(string(rs[:cut]), unicode.IsSpace))
+			rs = []rune(strings.TrimLeftFunc(string(rs[cut:]), unicode.IsSpace))
+		}
+	}
+	return lines
+}
+
+// breakable reports whether a line can break before rs[i].
+func breakable(rs []rune, i int) bool {
+	if i <= 0 || i >= len(rs) {
+		return false
+	}
+	return unicode.IsSpace(rs[i-1]) || unicode.IsSpace(rs[i]) ||
+		runewidth.RuneWidth(rs[i-1]) == 2 || runewidth.RuneWidth(rs[i]) == 2
+}
+
+// langHeader returns the header of the column of the language code, or
+// else def.
+func langHeader(code, def string) string {
+	if code == "" {
+		return def
+	}
+	return detectedName(code)
+}
+
+// writeMarkdownTable writes pairs as a Markdown table of the source
+// texts and the translations.
+func writeMarkdownTable(w io.Writer, pairs []pair, source, target string) {
+	cell := func(s string) string {
+		s = strings.TrimSpace(s)
+		s = strings.Replace(s, `\`, `\\`, -1)
+		s = strings.Replace(s, "|", `\|`, -1)
+		return strings.Replace(s, "\n", "<br>", -1)
+	}
+	fmt.Fprintf(w, "| %s | %s |\n", langHeader(source, "Source"), langHeader(target, "Translation"))
+	fmt.Fprintln(w, "| --- | --- |")
+	for _, p := range pairs {
+		if p.source == "" && p.translation == "" {
+			continue
+		}
+		fmt.Fprintf(w, "| %s | %s |\n", cell(p.source), cell(p.translation))
+	}
+}
+
+// writeHTMLPage writes pairs as an HTML page with a table of the source
+// texts and the translations, a section of which is a paragraph.
+func writeHTMLPage(w io.Writer, pairs []pair, source, target string) {
+	lang := func(code string) string {
+		if code == "" {
+			return ""
+		}
+		return ` lang="` + html.EscapeString(code) + `"`
+	}
+	cell := func(s string) string {
+		s = html.EscapeString(strings.TrimSpace(s))
+		return strings.Replace(s, "\n", "<br>\n", -1)
+	}
+	src := langHeader(source, "Source")
+	tgt := langHeader(target, "Translation")
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s → %s</title>
+<style>
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+tbody + tbody { border-top: 3px solid #ccc; }
+</style>
+</head>
+<body>
+<table>
+<thead>
+<tr><th>%s</th><th>%s</th></tr>
+</thead>
+`, html.EscapeString(src), html.EscapeString(tgt), html.EscapeString(src), html.EscapeString(tgt))
+	open := false
+	for _, p := range pairs {
+		if p.source == "" && p.translation == "" {
+			if open {
+				fmt.Fprintln(w, "</tbody>")
+				open = false
+			}
+			continue
+		}
+		if !open {
+			fmt.Fprintln(w, "<tbody>")
+			open = true
+		}
+		fmt.Fprintf(w, "<tr><td%s>%s</td><td%s>%s</td></tr>\n",
+			lang(source), cell(p.source), lang(target), cell(p.translation))
+	}
+	if open {
+		fmt.Fprintln(w, "</tbody>")
+	}
+	fmt.Fprint(w, "</table>\n</body>\n</html>\n")
+}
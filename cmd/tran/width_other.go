@@ -0,0 +1,10 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package main
+
+// terminalWidth returns false, for the width of the terminal is not
+// known on this platform.
+func terminalWidth(fd uintptr) (int, bool) {
+	return 0, false
+}
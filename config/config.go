@@ -3,6 +3,11 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"time"
 
 	"github.com/morikuni/aec"
 	"github.com/y-bash/go-tran"
@@ -28,8 +33,20 @@ type Config struct {
 	DefaultSourceName string
 	DefaultTargetCode string
 	DefaultTargetName string
-	APIEndpoint       tran.Endpoint
+	Translator        tran.Translator
 	APILimitNChars    int
+	APIKind           string
+	APITimeout        time.Duration
+	APIProxy          *url.URL
+	APIRetry          tran.RetryPolicy
+	APIBatch          tran.BatchOptions
+	CacheEnabled      bool
+	CachePath         string
+	CacheOptions      tran.CacheOptions
+	MemoryEnabled     bool
+	MemoryPath        string
+	MemoryOptions     tran.MemoryOptions
+	Glossaries        []*tran.Glossary
 	InfoColor         aec.ANSI
 	StateColor        aec.ANSI
 	ErrorColor        aec.ANSI
@@ -56,12 +73,69 @@ func (c *Config) ChangeDefault(source, target string) error {
 	return nil
 }
 
+// Kinds of the API server, specified by [api];kind.
+const (
+	kindGAS            = "gas"
+	kindLibreTranslate = "libretranslate"
+	kindCustom         = "custom"
+	kindPseudo         = "pseudo"
+)
+
+func httpOptions(timeout time.Duration, proxy *url.URL) *tran.Options {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return &tran.Options{
+		Client:    &http.Client{Transport: transport},
+		Timeout:   timeout,
+		UserAgent: "go-tran",
+	}
+}
+
+func newTranslator(api *API, opts *tran.Options) (tran.Translator, error) {
+	switch api.Kind {
+	case kindLibreTranslate:
+		return tran.NewLibreTranslate(api.Endpoint, api.Key, opts), nil
+	case kindCustom:
+		if api.Custom == nil {
+			return nil, errors.New("missing for kind \"custom\"")
+		}
+		return tran.NewCustomAPI(&tran.CustomSpec{
+			Method:     api.Custom.Method,
+			URL:        api.Endpoint,
+			BodyType:   api.Custom.BodyType,
+			Body:       api.Custom.Body,
+			Header:     api.Custom.Headers,
+			TextPath:   api.Custom.TextPath,
+			ErrorPath:  api.Custom.ErrorPath,
+			SourcePath: api.Custom.SourcePath,
+			Key:        api.Key,
+		}, opts)
+	case kindPseudo:
+		return tran.NewPseudo(), nil
+	default:
+		return tran.NewClient(tran.NewAPI(api.Endpoint), opts), nil
+	}
+}
+
 func initialToml() *Toml {
 	var initial Toml
 	initial.Default.Source = ""
 	initial.Default.Target, _ = tran.CurrentLang()
+	initial.API.Kind = kindGAS
 	initial.API.Endpoint = string(tran.DefaultAPI())
 	initial.API.LimitNChars = 4000
+	initial.API.Timeout = "30s"
+	initial.API.MaxRetries = tran.DefaultRetryPolicy.MaxRetries
+	initial.API.RetryWait = tran.DefaultRetryPolicy.MinBackoff.String()
+	initial.API.RetryMax = tran.DefaultRetryPolicy.MaxBackoff.String()
+	initial.API.Parallel = tran.DefaultBatchOptions.Parallel
+	initial.API.RateLimit = tran.DefaultBatchOptions.RequestsPerSecond
+	initial.Cache.MaxEntries = tran.DefaultCacheOptions.MaxEntries
+	initial.Cache.TTL = tran.DefaultCacheOptions.TTL.String()
+	initial.Memory.MaxEntries = tran.DefaultMemoryOptions.MaxEntries
+	initial.Memory.Threshold = tran.DefaultMemoryOptions.Threshold
 	initial.Colors.Info = cInfo
 	initial.Colors.State = cState
 	initial.Colors.Error = cError
@@ -93,11 +167,18 @@ func tomlToConfig(toml *Toml) (*Config, error) {
 	config.DefaultTargetCode = code
 	config.DefaultTargetName = name
 
-	config.APIEndpoint = tran.Endpoint(toml.API.Endpoint)
-	if len(config.APIEndpoint) <= 0 {
+	switch toml.API.Kind {
+	case kindGAS, kindLibreTranslate, kindCustom, kindPseudo:
+		config.APIKind = toml.API.Kind
+	default:
+		return nil, fmt.Errorf(
+			"config.toml;[api];kind is invalid: %q, want: %q, %q, %q or %q",
+			toml.API.Kind, kindGAS, kindLibreTranslate, kindCustom, kindPseudo)
+	}
+	if len(toml.API.Endpoint) <= 0 {
 		return nil, fmt.Errorf(
 			"config.toml;[api];endpoint is invalid: %q, want: url",
-			config.APIEndpoint)
+			toml.API.Endpoint)
 	}
 	config.APILimitNChars = toml.API.LimitNChars
 	if config.APILimitNChars <= 0 {
@@ -105,8 +186,94 @@ func tomlToConfig(toml *Toml) (*Config, error) {
 			"config.toml;[api];limit_n_chars is invalid: %d, want: positive number",
 			config.APILimitNChars)
 	}
+	timeout, err := time.ParseDuration(toml.API.Timeout)
+	if err != nil || timeout < 0 {
+		return nil, fmt.Errorf(
+			"config.toml;[api];timeout is invalid: %q, want: duration (e.g. \"30s\")",
+			toml.API.Timeout)
+	}
+	config.APITimeout = timeout
+	if toml.API.Proxy != "" {
+		proxy, err := url.Parse(toml.API.Proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf(
+				"config.toml;[api];proxy is invalid: %q, want: url",
+				toml.API.Proxy)
+		}
+		config.APIProxy = proxy
+	}
+	// A negative max_retries disables retries, since zero means the default.
+	config.APIRetry.MaxRetries = toml.API.MaxRetries
+	config.APIRetry.MinBackoff, err = time.ParseDuration(toml.API.RetryWait)
+	if err != nil || config.APIRetry.MinBackoff < 0 {
+		return nil, fmt.Errorf(
+			"config.toml;[api];retry_wait is invalid: %q, want: duration (e.g. \"500ms\")",
+			toml.API.RetryWait)
+	}
+	config.APIRetry.MaxBackoff, err = time.ParseDuration(toml.API.RetryMax)
+	if err != nil || config.APIRetry.MaxBackoff < config.APIRetry.MinBackoff {
+		return nil, fmt.Errorf(
+			"config.toml;[api];retry_max_wait is invalid: %q, want: duration not less than retry_wait",
+			toml.API.RetryMax)
+	}
+	config.APIBatch.Parallel = toml.API.Parallel
+	if config.APIBatch.Parallel <= 0 {
+		return nil, fmt.Errorf(
+			"config.toml;[api];parallel is invalid: %d, want: positive number",
+			toml.API.Parallel)
+	}
+	config.APIBatch.RequestsPerSecond = toml.API.RateLimit
+	if config.APIBatch.RequestsPerSecond < 0 {
+		return nil, fmt.Errorf(
+			"config.toml;[api];requests_per_second is invalid: %g, want: 0 (unlimited) or positive number",
+			toml.API.RateLimit)
+	}
+	config.Translator, err = newTranslator(&toml.API,
+		httpOptions(config.APITimeout, config.APIProxy))
+	if err != nil {
+		return nil, fmt.Errorf("config.toml;[api.custom] is invalid: %s", err)
+	}
+	if _, ok := config.Translator.(*tran.Pseudo); !ok {
+		// The pseudo-localization goes through the same wrappers.
+		config.Translator = tran.NewPseudoRouter(config.Translator)
+	}
+	patterns := make([]*regexp.Regexp, len(toml.Protect.Patterns))
+	for i, s := range toml.Protect.Patterns {
+		patterns[i], err = regexp.Compile(s)
+		if err != nil || s == "" {
+			return nil, fmt.Errorf(
+				"config.toml;[protect];patterns is invalid: %q, want: regular expression",
+				s)
+		}
+	}
+	config.Translator = tran.NewProtector(config.Translator, patterns...)
+	if config.APIRetry.MaxRetries > 0 {
+		config.Translator = tran.NewRetrier(config.Translator, config.APIRetry)
+	}
+
+	// A negative max_entries disables the cache, since zero means the default.
+	config.CacheEnabled = toml.Cache.MaxEntries > 0
+	// The backends of the same endpoint do not share the entries.
+	config.CacheOptions.Namespace = toml.API.Kind + " " + toml.API.Endpoint
+	config.CacheOptions.MaxEntries = toml.Cache.MaxEntries
+	config.CacheOptions.TTL, err = time.ParseDuration(toml.Cache.TTL)
+	if err != nil || config.CacheOptions.TTL < 0 {
+		return nil, fmt.Errorf(
+			"config.toml;[cache];ttl is invalid: %q, want: duration (e.g. \"720h\", \"0s\" for no expiry)",
+			toml.Cache.TTL)
+	}
+
+	// A negative max_entries disables the memory, since zero means the default.
+	config.MemoryEnabled = toml.Memory.MaxEntries > 0
+	config.MemoryOptions = tran.DefaultMemoryOptions
+	config.MemoryOptions.MaxEntries = toml.Memory.MaxEntries
+	config.MemoryOptions.Threshold = toml.Memory.Threshold
+	if config.MemoryOptions.Threshold > 1 {
+		return nil, fmt.Errorf(
+			"config.toml;[memory];fuzzy_threshold is invalid: %g, want: 1 or less (negative to disable)",
+			toml.Memory.Threshold)
+	}
 
-	var err error
 	config.InfoColor, err = hex2ansi(toml.Colors.Info)
 	if err != nil {
 		return nil, fmt.Errorf("config.toml;[colors];info is %s", err.Error())
@@ -133,5 +300,58 @@ func Load() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	return tomlToConfig(loaded)
+	config, err := tomlToConfig(loaded)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	config.CachePath = filepath.Join(dir, "cache.json")
+	config.MemoryPath = filepath.Join(dir, "memory.tmx")
+	config.Glossaries, err = loadGlossaries(loaded.Glossary, dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Glossaries) > 0 {
+		config.Translator = tran.NewGlossaryTranslator(config.Translator, config.Glossaries...)
+	}
+	return config, nil
+}
+
+// loadGlossaries loads the glossaries of [[glossary]], whose relative
+// paths are relative to dir.
+func loadGlossaries(entries []Glossary, dir string) ([]*tran.Glossary, error) {
+	var gs []*tran.Glossary
+	for _, e := range entries {
+		source := ""
+		if e.Source != "" {
+			code, _, ok := tran.LookupLangCode(e.Source)
+			if !ok {
+				return nil, fmt.Errorf(
+					"config.toml;[[glossary]];source is invalid: %q, want: language code", e.Source)
+			}
+			source = code
+		}
+		target, _, ok := tran.LookupLangCode(e.Target)
+		if !ok {
+			return nil, fmt.Errorf(
+				"config.toml;[[glossary]];target is invalid: %q, want: language code", e.Target)
+		}
+		if e.Path == "" {
+			return nil, fmt.Errorf(
+				"config.toml;[[glossary]];path is invalid: %q, want: path of csv or toml file", e.Path)
+		}
+		path := e.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		g, err := tran.LoadGlossary(path, source, target)
+		if err != nil {
+			return nil, fmt.Errorf("config.toml;[[glossary]];path is invalid: %s", err)
+		}
+		gs = append(gs, g)
+	}
+	return gs, nil
 }
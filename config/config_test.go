@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/morikuni/aec"
 	"github.com/y-bash/go-tran"
@@ -14,47 +17,192 @@ type TomlToConfigTest struct {
 	err    string
 }
 
+// validAPI and validCache are the valid sections used by the tests
+// of the other sections.
+var (
+	validAPI = API{Kind: "gas", Endpoint: "url", LimitNChars: 1, Timeout: "30s",
+		RetryWait: "1s", RetryMax: "1s", Parallel: 1}
+	validCache = Cache{MaxEntries: 1, TTL: "1h"}
+)
+
+func withAPI(f func(api *API)) API {
+	api := validAPI
+	f(&api)
+	return api
+}
+
 var tomltoconfigtests = []TomlToConfigTest{
 	0: {
 		Toml{
-			Default{"", "ja"}, API{"url", 3},
-			Colors{"#000000", "#000000", "#000000", "#000000"},
+			Default: Default{"", "ja"},
+			API: API{Kind: "gas", Endpoint: "url", LimitNChars: 3, Timeout: "30s",
+				RetryWait: "1s", RetryMax: "2s", Parallel: 4},
+			Cache:  Cache{MaxEntries: -1, TTL: "0s"},
+			Memory: Memory{MaxEntries: -1, Threshold: 0.5},
+			Colors: Colors{"#000000", "#000000", "#000000", "#000000"},
 		},
 		Config{
-			"", "Auto", "ja", "Japanese", tran.Endpoint("url"), 3,
-			aec.FullColorF(0x0, 0x0, 0x0), aec.FullColorF(0x0, 0x0, 0x0),
-			aec.FullColorF(0x0, 0x0, 0x0), aec.FullColorF(0x0, 0x0, 0x0),
+			DefaultSourceCode: "", DefaultSourceName: "Auto",
+			DefaultTargetCode: "ja", DefaultTargetName: "Japanese",
+			Translator: tran.NewClient("url", nil), APILimitNChars: 3, APIKind: "gas",
+			APITimeout:    30 * time.Second,
+			APIRetry:      tran.RetryPolicy{MaxRetries: 0, MinBackoff: time.Second, MaxBackoff: 2 * time.Second},
+			APIBatch:      tran.BatchOptions{Parallel: 4},
+			CacheEnabled:  false,
+			CacheOptions:  tran.CacheOptions{Namespace: "gas url", MaxEntries: -1},
+			MemoryOptions: tran.MemoryOptions{Threshold: 0.5, MaxMatches: 3, MaxEntries: -1},
+			InfoColor:     aec.FullColorF(0x0, 0x0, 0x0), StateColor: aec.FullColorF(0x0, 0x0, 0x0),
+			ErrorColor: aec.FullColorF(0x0, 0x0, 0x0), ResultColor: aec.FullColorF(0x0, 0x0, 0x0),
 		},
 		"",
 	},
 	1: {
 		Toml{
-			Default{"ja", "en"}, API{"uri", 4},
-			Colors{"#ffeedd", "#ccbbaa", "#998877", "#665544"},
+			Default: Default{"ja", "en"},
+			API: API{Kind: "libretranslate", Endpoint: "uri", Key: "k", LimitNChars: 4, Timeout: "1m", Proxy: "http://proxy:8080",
+				MaxRetries: 2, RetryWait: "100ms", RetryMax: "1s", Parallel: 2, RateLimit: 1.5},
+			Cache:  Cache{MaxEntries: 100, TTL: "24h"},
+			Memory: Memory{MaxEntries: 10, Threshold: -1},
+			Colors: Colors{"#ffeedd", "#ccbbaa", "#998877", "#665544"},
 		},
 		Config{
-			"ja", "Japanese", "en", "English", tran.Endpoint("uri"), 4,
-			aec.FullColorF(0xff, 0xee, 0xdd), aec.FullColorF(0xcc, 0xbb, 0xaa),
-			aec.FullColorF(0x99, 0x88, 0x77), aec.FullColorF(0x66, 0x55, 0x44),
+			DefaultSourceCode: "ja", DefaultSourceName: "Japanese",
+			DefaultTargetCode: "en", DefaultTargetName: "English",
+			Translator: tran.NewLibreTranslate("uri", "k", nil), APILimitNChars: 4,
+			APIKind:    "libretranslate",
+			APITimeout: time.Minute, APIProxy: &url.URL{Scheme: "http", Host: "proxy:8080"},
+			APIRetry:      tran.RetryPolicy{MaxRetries: 2, MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second},
+			APIBatch:      tran.BatchOptions{Parallel: 2, RequestsPerSecond: 1.5},
+			CacheEnabled:  true,
+			CacheOptions:  tran.CacheOptions{Namespace: "libretranslate uri", MaxEntries: 100, TTL: 24 * time.Hour},
+			MemoryEnabled: true,
+			MemoryOptions: tran.MemoryOptions{Threshold: -1, MaxMatches: 3, MaxEntries: 10},
+			InfoColor:     aec.FullColorF(0xff, 0xee, 0xdd), StateColor: aec.FullColorF(0xcc, 0xbb, 0xaa),
+			ErrorColor: aec.FullColorF(0x99, 0x88, 0x77), ResultColor: aec.FullColorF(0x66, 0x55, 0x44),
 		},
 		"",
 	},
-	2: {Toml{Default{"zz", ""}, API{}, Colors{}},
+	2: {Toml{Default: Default{"zz", ""}},
 		Config{}, "source is invalid"},
-	3: {Toml{Default{"", "zz"}, API{}, Colors{}},
+	3: {Toml{Default: Default{"", "zz"}},
 		Config{}, "target is invalid"},
-	4: {Toml{Default{"", "ja"}, API{"", 1}, Colors{}},
+	4: {Toml{Default: Default{"", "ja"}, API: withAPI(func(a *API) { a.Endpoint = "" })},
 		Config{}, "endpoint is invalid"},
-	5: {Toml{Default{"", "ja"}, API{"url", 0}, Colors{}},
+	5: {Toml{Default: Default{"", "ja"}, API: withAPI(func(a *API) { a.LimitNChars = 0 })},
 		Config{}, "limit_n_chars is invalid"},
-	6: {Toml{Default{"", "ja"}, API{"url", 1}, Colors{"#Z", "", "", ""}},
+	6: {Toml{Default: Default{"", "ja"}, API: validAPI, Cache: validCache,
+		Colors: Colors{"#Z", "", "", ""}},
 		Config{}, "info is invalid"},
-	7: {Toml{Default{"", "ja"}, API{"url", 1}, Colors{"#000000", "#Z", "", ""}},
+	7: {Toml{Default: Default{"", "ja"}, API: validAPI, Cache: validCache,
+		Colors: Colors{"#000000", "#Z", "", ""}},
 		Config{}, "state is invalid"},
-	8: {Toml{Default{"", "ja"}, API{"url", 1}, Colors{"#000000", "#000000", "#Z", ""}},
+	8: {Toml{Default: Default{"", "ja"}, API: validAPI, Cache: validCache,
+		Colors: Colors{"#000000", "#000000", "#Z", ""}},
 		Config{}, "error is invalid"},
-	9: {Toml{Default{"", "ja"}, API{"url", 1}, Colors{"#000000", "#000000", "#000000", "#Z"}},
+	9: {Toml{Default: Default{"", "ja"}, API: validAPI, Cache: validCache,
+		Colors: Colors{"#000000", "#000000", "#000000", "#Z"}},
 		Config{}, "result is invalid"},
+	10: {Toml{Default: Default{"", "ja"}, API: withAPI(func(a *API) { a.Timeout = "soon" })},
+		Config{}, "timeout is invalid"},
+	11: {Toml{Default: Default{"", "ja"}, API: withAPI(func(a *API) { a.Proxy = "::" })},
+		Config{}, "proxy is invalid"},
+	12: {Toml{Default: Default{"", "ja"}, API: withAPI(func(a *API) { a.RetryWait = "x" })},
+		Config{}, "retry_wait is invalid"},
+	13: {Toml{Default: Default{"", "ja"}, API: withAPI(func(a *API) { a.RetryWait = "2s" })},
+		Config{}, "retry_max_wait is invalid"},
+	14: {Toml{Default: Default{"", "ja"}, API: withAPI(func(a *API) { a.Parallel = 0 })},
+		Config{}, "parallel is invalid"},
+	15: {Toml{Default: Default{"", "ja"}, API: withAPI(func(a *API) { a.RateLimit = -1 })},
+		Config{}, "requests_per_second is invalid"},
+	16: {Toml{Default: Default{"", "ja"}, API: validAPI, Cache: Cache{MaxEntries: 1, TTL: "1 month"}},
+		Config{}, "ttl is invalid"},
+	17: {Toml{Default: Default{"", "ja"}, API: withAPI(func(a *API) { a.Kind = "deepl" })},
+		Config{}, "kind is invalid"},
+	18: {Toml{Default: Default{"", "ja"}, Cache: validCache,
+		API: withAPI(func(a *API) {
+			a.Kind = "custom"
+			a.Key = "k"
+			a.Custom = &Custom{BodyType: "json", Body: `{"q": {{json .Text}}}`,
+				Headers: map[string]string{"Authorization": "Bearer {{.Key}}"}, TextPath: "$.text"}
+		}),
+		Colors: Colors{"#000000", "#000000", "#000000", "#000000"}},
+		Config{
+			DefaultSourceCode: "", DefaultSourceName: "Auto",
+			DefaultTargetCode: "ja", DefaultTargetName: "Japanese",
+			Translator:     &tran.CustomAPI{Spec: tran.CustomSpec{URL: "url", Key: "k"}},
+			APILimitNChars: 1, APIKind: "custom", APITimeout: 30 * time.Second,
+			APIRetry:     tran.RetryPolicy{MinBackoff: time.Second, MaxBackoff: time.Second},
+			APIBatch:     tran.BatchOptions{Parallel: 1},
+			CacheEnabled: true,
+			CacheOptions: tran.CacheOptions{Namespace: "custom url", MaxEntries: 1, TTL: time.Hour},
+			InfoColor:    aec.FullColorF(0x0, 0x0, 0x0), StateColor: aec.FullColorF(0x0, 0x0, 0x0),
+			ErrorColor: aec.FullColorF(0x0, 0x0, 0x0), ResultColor: aec.FullColorF(0x0, 0x0, 0x0),
+		},
+		""},
+	19: {Toml{Default: Default{"", "ja"}, API: withAPI(func(a *API) { a.Kind = "custom" })},
+		Config{}, "[api.custom] is invalid"},
+	20: {Toml{Default: Default{"", "ja"}, API: withAPI(func(a *API) {
+		a.Kind = "custom"
+		a.Custom = &Custom{BodyType: "xml", TextPath: "$.text"}
+	})},
+		Config{}, "body_type is invalid"},
+	21: {Toml{Default: Default{"", "qps"}, Cache: validCache,
+		API:    withAPI(func(a *API) { a.Kind = "pseudo" }),
+		Colors: Colors{"#000000", "#000000", "#000000", "#000000"}},
+		Config{
+			DefaultSourceCode: "", DefaultSourceName: "Auto",
+			DefaultTargetCode: "qps", DefaultTargetName: "Pseudo",
+			Translator:     tran.NewPseudo(),
+			APILimitNChars: 1, APIKind: "pseudo", APITimeout: 30 * time.Second,
+			APIRetry:     tran.RetryPolicy{MinBackoff: time.Second, MaxBackoff: time.Second},
+			APIBatch:     tran.BatchOptions{Parallel: 1},
+			CacheEnabled: true,
+			CacheOptions: tran.CacheOptions{Namespace: "pseudo url", MaxEntries: 1, TTL: time.Hour},
+			InfoColor:    aec.FullColorF(0x0, 0x0, 0x0), StateColor: aec.FullColorF(0x0, 0x0, 0x0),
+			ErrorColor: aec.FullColorF(0x0, 0x0, 0x0), ResultColor: aec.FullColorF(0x0, 0x0, 0x0),
+		},
+		""},
+	22: {Toml{Default: Default{"", "ja"}, API: validAPI, Cache: validCache,
+		Protect: Protect{Patterns: []string{`:\w+:`, `(`}}},
+		Config{}, "[protect];patterns is invalid"},
+	23: {Toml{Default: Default{"", "ja"}, API: validAPI, Cache: validCache,
+		Memory: Memory{MaxEntries: 1, Threshold: 1.5}},
+		Config{}, "fuzzy_threshold is invalid"},
+}
+
+func endpointOf(tr tran.Translator) string {
+	if r, ok := tr.(*tran.Retrier); ok {
+		tr = r.Translator
+	}
+	if p, ok := tr.(*tran.Protector); ok {
+		tr = p.Translator
+	}
+	if p, ok := tr.(*tran.PseudoRouter); ok {
+		tr = p.Translator
+	}
+	switch tr := tr.(type) {
+	case *tran.Client:
+		return "gas:" + string(tr.Endpoint)
+	case *tran.LibreTranslate:
+		return "libretranslate:" + tr.URL + ":" + tr.APIKey
+	case *tran.CustomAPI:
+		return "custom:" + tr.Spec.URL + ":" + tr.Spec.Key
+	case *tran.Pseudo:
+		return "pseudo:"
+	}
+	return ""
+}
+
+func TestTomlToConfig_Pseudo(t *testing.T) {
+	config, err := tomlToConfig(&tomltoconfigtests[0].toml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pseudo-localized by the API of any kind, through the protector.
+	out, err := config.Translator.Translate("<b>abc</b>", "en", tran.PseudoTarget)
+	if want := "[<b>àƀç</b> ~]"; err != nil || out != want {
+		t.Errorf("Translate() = (%q, %v), want: (%q, nil)", out, err, want)
+	}
 }
 
 func TestTomlToConfig(t *testing.T) {
@@ -89,14 +237,54 @@ func TestTomlToConfig(t *testing.T) {
 			t.Errorf("#%d have: config.DefaultTargetName = %s, want: %s",
 				i, config.DefaultTargetName, tt.config.DefaultTargetName)
 		}
-		if config.APIEndpoint != tt.config.APIEndpoint {
-			t.Errorf("#%d have: config.APIEndpoint = %s, want: %s",
-				i, config.APIEndpoint, tt.config.APIEndpoint)
+		if endpointOf(config.Translator) != endpointOf(tt.config.Translator) {
+			t.Errorf("#%d have: config.Translator endpoint = %s, want: %s",
+				i, endpointOf(config.Translator), endpointOf(tt.config.Translator))
 		}
 		if config.APILimitNChars != tt.config.APILimitNChars {
 			t.Errorf("#%d have: config.APILimitNChars = %d, want: %d",
 				i, config.APILimitNChars, tt.config.APILimitNChars)
 		}
+		if config.APIKind != tt.config.APIKind {
+			t.Errorf("#%d have: config.APIKind = %s, want: %s",
+				i, config.APIKind, tt.config.APIKind)
+		}
+		if config.APITimeout != tt.config.APITimeout {
+			t.Errorf("#%d have: config.APITimeout = %v, want: %v",
+				i, config.APITimeout, tt.config.APITimeout)
+		}
+		if fmt.Sprint(config.APIProxy) != fmt.Sprint(tt.config.APIProxy) {
+			t.Errorf("#%d have: config.APIProxy = %v, want: %v",
+				i, config.APIProxy, tt.config.APIProxy)
+		}
+		if config.APIRetry != tt.config.APIRetry {
+			t.Errorf("#%d have: config.APIRetry = %v, want: %v",
+				i, config.APIRetry, tt.config.APIRetry)
+		}
+		if config.APIBatch != tt.config.APIBatch {
+			t.Errorf("#%d have: config.APIBatch = %v, want: %v",
+				i, config.APIBatch, tt.config.APIBatch)
+		}
+		if _, ok := config.Translator.(*tran.Retrier); ok != (tt.config.APIRetry.MaxRetries > 0) {
+			t.Errorf("#%d have: config.Translator = %T, want: retrier: %v",
+				i, config.Translator, tt.config.APIRetry.MaxRetries > 0)
+		}
+		if config.CacheEnabled != tt.config.CacheEnabled {
+			t.Errorf("#%d have: config.CacheEnabled = %v, want: %v",
+				i, config.CacheEnabled, tt.config.CacheEnabled)
+		}
+		if config.CacheOptions != tt.config.CacheOptions {
+			t.Errorf("#%d have: config.CacheOptions = %v, want: %v",
+				i, config.CacheOptions, tt.config.CacheOptions)
+		}
+		if config.MemoryEnabled != tt.config.MemoryEnabled {
+			t.Errorf("#%d have: config.MemoryEnabled = %v, want: %v",
+				i, config.MemoryEnabled, tt.config.MemoryEnabled)
+		}
+		if tt.toml.Memory != (Memory{}) && config.MemoryOptions != tt.config.MemoryOptions {
+			t.Errorf("#%d have: config.MemoryOptions = %v, want: %v",
+				i, config.MemoryOptions, tt.config.MemoryOptions)
+		}
 		if config.InfoColor.String() != tt.config.InfoColor.String() {
 			t.Errorf("#%d have: config.InfoColor = %s, want: %s",
 				i, config.InfoColor.String(), tt.config.InfoColor.String())
@@ -115,3 +303,48 @@ func TestTomlToConfig(t *testing.T) {
 		}
 	}
 }
+
+type LoadGlossariesTest struct {
+	entries []Glossary
+	n       int
+	err     string
+}
+
+var loadglossariestests = []LoadGlossariesTest{
+	0: {nil, 0, ""},
+	1: {[]Glossary{{"", "ja", "glossary.csv"}, {"EN", "fr", "glossary.csv"}}, 2, ""},
+	2: {[]Glossary{{"zz", "ja", "glossary.csv"}}, 0, "source is invalid"},
+	3: {[]Glossary{{"en", "", "glossary.csv"}}, 0, "target is invalid"},
+	4: {[]Glossary{{"en", "ja", ""}}, 0, "path is invalid"},
+	5: {[]Glossary{{"en", "ja", "notexists.csv"}}, 0, "path is invalid"},
+	6: {[]Glossary{{"en", "ja", "exists.txt"}}, 0, "unknown glossary format"},
+}
+
+func TestLoadGlossaries(t *testing.T) {
+	for i, tt := range loadglossariestests {
+		gs, err := loadGlossaries(tt.entries, "testdata")
+		if err != nil {
+			if tt.err == "" || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("#%d have error: %s, want error: %q", i, err, tt.err)
+			}
+			continue
+		}
+		if tt.err != "" {
+			t.Errorf("#%d have error: nil, want error: %q", i, tt.err)
+			continue
+		}
+		if len(gs) != tt.n {
+			t.Errorf("#%d have: %d glossaries, want: %d", i, len(gs), tt.n)
+			continue
+		}
+		for j, g := range gs {
+			if g.Target != tt.entries[j].Target || len(g.Terms) != 2 {
+				t.Errorf("#%d have: glossary %d = %+v, want: target %s with 2 terms",
+					i, j, g, tt.entries[j].Target)
+			}
+		}
+	}
+	if gs, _ := loadGlossaries(loadglossariestests[1].entries, "testdata"); len(gs) == 2 && gs[1].Source != "en" {
+		t.Errorf("have: source = %q, want: %q", gs[1].Source, "en")
+	}
+}
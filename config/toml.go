@@ -14,8 +14,40 @@ type Default struct {
 }
 
 type API struct {
-	Endpoint    string `toml:"endpoint"`
-	LimitNChars int    `toml:"limit_n_chars"`
+	Kind        string  `toml:"kind"`
+	Endpoint    string  `toml:"endpoint"`
+	Key         string  `toml:"api_key"`
+	LimitNChars int     `toml:"limit_n_chars"`
+	Timeout     string  `toml:"timeout"`
+	Proxy       string  `toml:"proxy"`
+	MaxRetries  int     `toml:"max_retries"`
+	RetryWait   string  `toml:"retry_wait"`
+	RetryMax    string  `toml:"retry_max_wait"`
+	Parallel    int     `toml:"parallel"`
+	RateLimit   float64 `toml:"requests_per_second"`
+	Custom      *Custom `toml:"custom"`
+}
+
+// Custom describes the requests and responses of the server for
+// [api];kind = "custom". The URL is [api];endpoint.
+type Custom struct {
+	Method     string            `toml:"method"`
+	BodyType   string            `toml:"body_type"`
+	Body       string            `toml:"body"`
+	Headers    map[string]string `toml:"headers"`
+	TextPath   string            `toml:"text_path"`
+	ErrorPath  string            `toml:"error_path"`
+	SourcePath string            `toml:"source_path"`
+}
+
+type Cache struct {
+	MaxEntries int    `toml:"max_entries"`
+	TTL        string `toml:"ttl"`
+}
+
+type Memory struct {
+	MaxEntries int     `toml:"max_entries"`
+	Threshold  float64 `toml:"fuzzy_threshold"`
 }
 
 type Colors struct {
@@ -25,10 +57,30 @@ type Colors struct {
 	Result string `toml:"result"`
 }
 
+// Protect describes the placeholders protected from the translation in
+// addition to tran.DefaultPlaceholders, as regular expressions.
+type Protect struct {
+	Patterns []string `toml:"patterns"`
+}
+
+// Glossary refers to the glossary file of a language pair, which is a
+// CSV or TOML file read by tran.ReadGlossary. A relative path is
+// relative to the directory of config.toml, and an empty source means
+// any language.
+type Glossary struct {
+	Source string `toml:"source"`
+	Target string `toml:"target"`
+	Path   string `toml:"path"`
+}
+
 type Toml struct {
-	Default Default `toml:"default"`
-	API     API     `toml:"api"`
-	Colors  Colors  `toml:"colors"`
+	Default  Default    `toml:"default"`
+	API      API        `toml:"api"`
+	Cache    Cache      `toml:"cache"`
+	Memory   Memory     `toml:"memory"`
+	Colors   Colors     `toml:"colors"`
+	Protect  Protect    `toml:"protect"`
+	Glossary []Glossary `toml:"glossary"`
 }
 
 func exists(path string) bool {
@@ -45,6 +97,10 @@ func (t *Toml) complete(initial *Toml) (overwritten bool) {
 		t.Default.Target = initial.Default.Target
 		overwritten = true
 	}
+	if t.API.Kind == "" {
+		t.API.Kind = initial.API.Kind
+		overwritten = true
+	}
 	if t.API.Endpoint == "" {
 		t.API.Endpoint = initial.API.Endpoint
 		overwritten = true
@@ -53,6 +109,42 @@ func (t *Toml) complete(initial *Toml) (overwritten bool) {
 		t.API.LimitNChars = initial.API.LimitNChars
 		overwritten = true
 	}
+	if t.API.Timeout == "" {
+		t.API.Timeout = initial.API.Timeout
+		overwritten = true
+	}
+	if t.API.MaxRetries == 0 {
+		t.API.MaxRetries = initial.API.MaxRetries
+		overwritten = true
+	}
+	if t.API.RetryWait == "" {
+		t.API.RetryWait = initial.API.RetryWait
+		overwritten = true
+	}
+	if t.API.RetryMax == "" {
+		t.API.RetryMax = initial.API.RetryMax
+		overwritten = true
+	}
+	if t.API.Parallel == 0 {
+		t.API.Parallel = initial.API.Parallel
+		overwritten = true
+	}
+	if t.Cache.MaxEntries == 0 {
+		t.Cache.MaxEntries = initial.Cache.MaxEntries
+		overwritten = true
+	}
+	if t.Cache.TTL == "" {
+		t.Cache.TTL = initial.Cache.TTL
+		overwritten = true
+	}
+	if t.Memory.MaxEntries == 0 {
+		t.Memory.MaxEntries = initial.Memory.MaxEntries
+		overwritten = true
+	}
+	if t.Memory.Threshold == 0 {
+		t.Memory.Threshold = initial.Memory.Threshold
+		overwritten = true
+	}
 	if t.Colors.Info == "" {
 		t.Colors.Info = initial.Colors.Info
 		overwritten = true
@@ -72,7 +164,9 @@ func (t *Toml) complete(initial *Toml) (overwritten bool) {
 	return
 }
 
-func getTomlPath() (path string, err error) {
+// Dir returns the directory where config.toml is stored,
+// creating it if it does not exist.
+func Dir() (string, error) {
 	var cfgdir string
 	if runtime.GOOS == "windows" {
 		appdir := os.Getenv("APPDATA")
@@ -85,7 +179,14 @@ func getTomlPath() (path string, err error) {
 		home := os.Getenv("HOME")
 		cfgdir = filepath.Join(home, ".config", "y-bash", "tran")
 	}
-	err = os.MkdirAll(cfgdir, 0700)
+	if err := os.MkdirAll(cfgdir, 0700); err != nil {
+		return "", err
+	}
+	return cfgdir, nil
+}
+
+func getTomlPath() (path string, err error) {
+	cfgdir, err := Dir()
 	if err != nil {
 		return "", err
 	}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -66,25 +69,26 @@ func TestLoadTomlFrom(t *testing.T) {
 	initial2.Colors.Error = "#BBBBBB"
 	initial2.Colors.Result = "#CCCCCC"
 
-	err := os.MkdirAll("../output", 0700)
+	dir, err := ioutil.TempDir("", "go-tran")
 	if err != nil {
 		t.Errorf("testdata is failed: %s", err.Error())
 		return
 	}
+	defer os.RemoveAll(dir)
 
 	// Not Exists Test
-	notExistsFile := "../output/load_notexists.toml"
+	notExistsFile := filepath.Join(dir, "load_notexists.toml")
 	loaded, err := loadTomlFrom(notExistsFile, &initial1)
 	if err != nil {
 		t.Errorf("testdata is failed: %s", err.Error())
 		return
 	}
-	if *loaded != initial1 {
+	if !reflect.DeepEqual(*loaded, initial1) {
 		t.Errorf("loadTomlFrom(notexists, initial1) != initial1")
 	}
 
 	// Empty Toml Test
-	emptyFile := "../output/load_empty.toml"
+	emptyFile := filepath.Join(dir, "load_empty.toml")
 	err = copyFile(emptyFile, "testdata/load_empty.toml")
 	if err != nil {
 		t.Errorf("testdata is failed: %s", err.Error())
@@ -95,7 +99,7 @@ func TestLoadTomlFrom(t *testing.T) {
 		t.Errorf("testdata is failed: %s", err.Error())
 		return
 	}
-	if *loaded != initial1 {
+	if !reflect.DeepEqual(*loaded, initial1) {
 		t.Errorf("loadTomlFrom(empty, initial1) != initial1")
 	}
 
@@ -106,7 +110,7 @@ func TestLoadTomlFrom(t *testing.T) {
 		t.Errorf("testdata is failed: %s", err.Error())
 		return
 	}
-	if *loaded != initial1 {
+	if !reflect.DeepEqual(*loaded, initial1) {
 		t.Errorf("loadTomlFrom(filled, initial2) != initial1")
 	}
 }
@@ -0,0 +1,271 @@
+package tran
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// CustomSpec describes the requests and responses of a custom HTTP
+// API server.
+//
+// URL, Body and the values of Header are templates (text/template)
+// executed with the fields Text, Source, Target and Key. The template
+// function "json" encodes its argument as JSON, and "urlquery" (built
+// in) escapes it for a URL or a form.
+//
+// TextPath, ErrorPath and SourcePath select a value of the JSON
+// response, such as "$.data.translations[0].translatedText".
+type CustomSpec struct {
+	Method     string            // default: POST
+	URL        string            // template
+	BodyType   string            // "form" or "json"
+	Body       string            // template
+	Header     map[string]string // values are templates
+	TextPath   string
+	ErrorPath  string // optional
+	SourcePath string // optional, the detected source language
+	Key        string // passed to the templates as .Key
+}
+
+// CustomAPI is a Translator which sends requests to a server as
+// described by a CustomSpec.
+type CustomAPI struct {
+	Spec    CustomSpec
+	Options Options
+
+	url    *template.Template
+	body   *template.Template
+	header map[string]*template.Template
+}
+
+type customData struct {
+	Text   string
+	Source string
+	Target string
+	Key    string
+}
+
+var customFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		buf, err := json.Marshal(v)
+		return string(buf), err
+	},
+}
+
+// NewCustomAPI returns a CustomAPI for spec. A nil opts means the zero
+// Options.
+func NewCustomAPI(spec *CustomSpec, opts *Options) (*CustomAPI, error) {
+	c := &CustomAPI{Spec: *spec, header: map[string]*template.Template{}}
+	if opts != nil {
+		c.Options = *opts
+	}
+	if c.Spec.Method == "" {
+		c.Spec.Method = http.MethodPost
+	}
+	switch c.Spec.BodyType {
+	case "form", "json":
+	case "":
+		if c.Spec.Body != "" {
+			return nil, errors.New("body_type is required for body")
+		}
+	default:
+		return nil, fmt.Errorf("body_type is invalid: %q, want: \"form\" or \"json\"",
+			c.Spec.BodyType)
+	}
+	if c.Spec.TextPath == "" {
+		return nil, errors.New("text_path is required")
+	}
+	for _, p := range []string{c.Spec.TextPath, c.Spec.ErrorPath, c.Spec.SourcePath} {
+		if _, err := parseJSONPath(p); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if c.url, err = newCustomTemplate("url", c.Spec.URL); err != nil {
+		return nil, err
+	}
+	if c.body, err = newCustomTemplate("body", c.Spec.Body); err != nil {
+		return nil, err
+	}
+	for k, v := range c.Spec.Header {
+		if c.header[k], err = newCustomTemplate(k, v); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func newCustomTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(customFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func execute(t *template.Template, data *customData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (c *CustomAPI) Translate(text, source, target string) (string, error) {
+	return c.TranslateContext(context.Background(), text, source, target)
+}
+
+func (c *CustomAPI) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	r, err := c.TranslateResult(ctx, text, source, target)
+	if err != nil {
+		return "", err
+	}
+	return r.Text, nil
+}
+
+// TranslateResult is like TranslateContext, but also returns the source
+// language selected by SourcePath if source is empty.
+func (c *CustomAPI) TranslateResult(ctx context.Context, text, source, target string) (*Result, error) {
+	data := &customData{text, source, target, c.Spec.Key}
+	url, err := execute(c.url, data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := execute(c.body, data)
+	if err != nil {
+		return nil, err
+	}
+	opts := c.Options
+	opts.Header = opts.Header.Clone()
+	if opts.Header == nil {
+		opts.Header = http.Header{}
+	}
+	for k, t := range c.header {
+		v, err := execute(t, data)
+		if err != nil {
+			return nil, err
+		}
+		opts.Header.Set(k, v)
+	}
+	contentType := ""
+	switch c.Spec.BodyType {
+	case "form":
+		contentType = "application/x-www-form-urlencoded"
+	case "json":
+		contentType = "application/json"
+	}
+
+	resp, buf, err := opts.send(ctx, c.Spec.Method, url, contentType, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	jerr := json.Unmarshal(buf, &v)
+	if c.Spec.ErrorPath != "" && jerr == nil {
+		if msg, err := selectJSON(v, c.Spec.ErrorPath); err == nil && msg != nil && msg != "" {
+			return nil, &APIError{Code: resp.StatusCode, Message: fmt.Sprint(msg)}
+		}
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, httpError(resp)
+	}
+	if jerr != nil {
+		return nil, invalidResponse(jerr)
+	}
+	out, err := selectJSON(v, c.Spec.TextPath)
+	if err != nil {
+		return nil, invalidResponse(err)
+	}
+	s, ok := out.(string)
+	if !ok {
+		return nil, invalidResponse(fmt.Errorf("%s is not a string", c.Spec.TextPath))
+	}
+	r := &Result{Text: s, Source: source}
+	if r.Source == "" && c.Spec.SourcePath != "" {
+		if v, err := selectJSON(v, c.Spec.SourcePath); err == nil {
+			if code, ok := v.(string); ok {
+				r.Source = code
+			}
+		}
+	}
+	return r, nil
+}
+
+func (c *CustomAPI) Languages() (ISO639List, error) {
+	return AllLangList(), nil
+}
+
+func (c *CustomAPI) Detect(text string) (string, error) {
+	return "", ErrNotSupported
+}
+
+// parseJSONPath splits a path such as "$.a.b[0].c" into its keys
+// (string) and indices (int).
+func parseJSONPath(path string) ([]interface{}, error) {
+	p := strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	var steps []interface{}
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path: %q", path)
+			}
+			in := p[1:end]
+			if n, err := strconv.Atoi(in); err == nil {
+				steps = append(steps, n)
+			} else if s, err := strconv.Unquote(in); err == nil {
+				steps = append(steps, s)
+			} else if len(in) >= 2 && in[0] == '\'' && in[len(in)-1] == '\'' {
+				steps = append(steps, in[1:len(in)-1])
+			} else {
+				return nil, fmt.Errorf("invalid path: %q", path)
+			}
+			p = p[end+1:]
+		default:
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			steps = append(steps, p[:end])
+			p = p[end:]
+		}
+	}
+	return steps, nil
+}
+
+// selectJSON returns the value at path in v decoded by encoding/json.
+func selectJSON(v interface{}, path string) (interface{}, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, step := range steps {
+		switch step := step.(type) {
+		case string:
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: %q is not found", path, step)
+			}
+			if v, ok = m[step]; !ok {
+				return nil, fmt.Errorf("%s: %q is not found", path, step)
+			}
+		case int:
+			a, ok := v.([]interface{})
+			if !ok || step < 0 || step >= len(a) {
+				return nil, fmt.Errorf("%s: [%d] is not found", path, step)
+			}
+			v = a[step]
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,159 @@
+package tran
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type SelectJSONTest struct {
+	json string
+	path string
+	out  string
+	err  bool
+}
+
+var selectjsontests = []SelectJSONTest{
+	0: {`{"text": "Cat"}`, "$.text", "Cat", false},
+	1: {`{"text": "Cat"}`, "text", "Cat", false},
+	2: {`{"data": {"translations": [{"t": "a"}, {"t": "b"}]}}`,
+		"$.data.translations[1].t", "b", false},
+	3: {`{"data": {"a b": "c"}}`, `$.data["a b"]`, "c", false},
+	4: {`{"data": {"a b": "c"}}`, `$.data['a b']`, "c", false},
+	5: {`[["Katze", "猫"]]`, "$[0][0]", "Katze", false},
+	6: {`{"text": "Cat"}`, "$.message", "", true},
+	7: {`{"a": [1]}`, "$.a[1]", "", true},
+	8: {`{"a": [1]}`, "$.a[x]", "", true},
+}
+
+func TestSelectJSON(t *testing.T) {
+	for i, tt := range selectjsontests {
+		var v interface{}
+		if err := json.Unmarshal([]byte(tt.json), &v); err != nil {
+			t.Fatal(err)
+		}
+		out, err := selectJSON(v, tt.path)
+		if (err != nil) != tt.err {
+			t.Errorf("#%d selectJSON(%s, %q) have error: %v, want error: %v",
+				i, tt.json, tt.path, err, tt.err)
+			continue
+		}
+		if err == nil && fmt.Sprint(out) != tt.out {
+			t.Errorf("#%d selectJSON(%s, %q) = %v, want: %v",
+				i, tt.json, tt.path, out, tt.out)
+		}
+	}
+}
+
+// gasSpec describes the protocol of the API script as a CustomSpec.
+var gasSpec = CustomSpec{
+	BodyType:  "form",
+	Body:      "text={{urlquery .Text}}&source={{urlquery .Source}}&target={{urlquery .Target}}",
+	TextPath:  "$.text",
+	ErrorPath: "$.message",
+}
+
+func TestCustomAPI_Form(t *testing.T) {
+	s := newAPIServer()
+	defer s.Close()
+	spec := gasSpec
+	spec.URL = s.URL
+	c, err := NewCustomAPI(&spec, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Translate("猫 & 犬", "ja", "en")
+	if err != nil || out != "猫 & 犬" {
+		t.Errorf("Translate() = (%q, %v), want: (%q, nil)", out, err, "猫 & 犬")
+	}
+	if r := s.Requests()[0]; r.Text != "猫 & 犬" || r.Source != "ja" || r.Target != "en" {
+		t.Errorf("request = %+v, want: {猫 & 犬 ja en}", r)
+	}
+	out, err = c.Translate("猫", "", "de")
+	if err != nil || out != "Katze" {
+		t.Errorf("Translate() = (%q, %v), want: (%q, nil)", out, err, "Katze")
+	}
+	_, err = c.Translate("猫", "", "xx")
+	if !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("have error: %v, want error: %v", err, ErrUnsupportedLanguage)
+	}
+}
+
+func TestCustomAPI_JSON(t *testing.T) {
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		var p struct{ Q, Target string }
+		json.NewDecoder(r.Body).Decode(&p)
+		if p.Target == "xx" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": {"message": "target not supported"}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"data": {"translations": [{"translatedText": %q, "lang": "en"}]}}`,
+			p.Q+"@"+p.Target+r.URL.Query().Get("v"))
+	}))
+	defer ts.Close()
+
+	c, err := NewCustomAPI(&CustomSpec{
+		URL:        ts.URL + "/translate?v={{.Key}}",
+		BodyType:   "json",
+		Body:       `{"q": {{json .Text}}, "target": {{json .Target}}}`,
+		Header:     map[string]string{"Authorization": "Bearer {{.Key}}"},
+		TextPath:   "$.data.translations[0].translatedText",
+		ErrorPath:  "$.error.message",
+		SourcePath: "$.data.translations[0].lang",
+		Key:        "k1",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Translate(`say "hi"`, "", "ja")
+	if err != nil || out != `say "hi"@jak1` {
+		t.Errorf("Translate() = (%q, %v), want: (%q, nil)", out, err, `say "hi"@jak1`)
+	}
+	r, err := c.TranslateResult(context.Background(), "cat", "", "ja")
+	if err != nil || r.Source != "en" {
+		t.Errorf("TranslateResult() = (%+v, %v), want: source en", r, err)
+	}
+	if auth != "Bearer k1" {
+		t.Errorf("Authorization = %q, want: %q", auth, "Bearer k1")
+	}
+	_, err = c.Translate("cat", "", "xx")
+	var ae *APIError
+	if !errors.As(err, &ae) || ae.Code != 400 || !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("have error: %#v, want error: *APIError{400, target not supported}", err)
+	}
+}
+
+type NewCustomAPITest struct {
+	spec CustomSpec
+	err  string
+}
+
+var newcustomapitests = []NewCustomAPITest{
+	0: {CustomSpec{URL: "u", TextPath: "$.t"}, ""},
+	1: {CustomSpec{URL: "u"}, "text_path is required"},
+	2: {CustomSpec{URL: "u", TextPath: "$.t", BodyType: "xml"}, "body_type is invalid"},
+	3: {CustomSpec{URL: "u", TextPath: "$.t", Body: "x"}, "body_type is required"},
+	4: {CustomSpec{URL: "{{.Text", TextPath: "$.t"}, "unclosed action"},
+	5: {CustomSpec{URL: "u", TextPath: "$.t[0"}, "invalid path"},
+}
+
+func TestNewCustomAPI(t *testing.T) {
+	for i, tt := range newcustomapitests {
+		_, err := NewCustomAPI(&tt.spec, nil)
+		have := ""
+		if err != nil {
+			have = err.Error()
+		}
+		if (tt.err == "") != (have == "") || !strings.Contains(have, tt.err) {
+			t.Errorf("#%d NewCustomAPI() have error: %q, want error: %q", i, have, tt.err)
+		}
+	}
+}
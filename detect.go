@@ -0,0 +1,239 @@
+package tran
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Detection is a language detected by Detect.
+type Detection struct {
+	Code       string
+	Name       string
+	Confidence float64 // from 0 to 1
+}
+
+// script is a writing system and the language it is used for by
+// default.
+type script struct {
+	table    *unicode.RangeTable
+	code     string
+	profiles []*profile // to tell the languages sharing the script
+}
+
+// profile holds the common words and the characteristic letters of a
+// language.
+type profile struct {
+	code    string
+	words   map[string]bool
+	letters string
+}
+
+func newProfile(code, words, letters string) *profile {
+	p := &profile{code: code, words: map[string]bool{}, letters: letters}
+	for _, w := range strings.Fields(words) {
+		p.words[w] = true
+	}
+	return p
+}
+
+// score returns the number of the common words and the characteristic
+// letters of p in words.
+func (p *profile) score(words []string) float64 {
+	var n float64
+	for _, w := range words {
+		if p.words[w] {
+			n++
+		}
+		for _, r := range w {
+			if strings.ContainsRune(p.letters, r) {
+				n += 0.5
+			}
+		}
+	}
+	return n
+}
+
+var latinProfiles = []*profile{
+	newProfile("en", "the of and to in is that it for was with as on be are this you not have by he she they we his her from at", ""),
+	newProfile("fr", "le la les de des et est un une du que qui dans pour pas sur au ce il je nous vous avec sont aux cette", "çèêœ"),
+	newProfile("de", "der die das und ist nicht ein eine zu den von mit sich auf für ich es im dem sie auch wir wird", "äöüß"),
+	newProfile("es", "el la de que y en los del se las por un una para con no es al lo como más pero su está", "ñ¿¡"),
+	newProfile("it", "il la di che e è un una per non del della le gli in con si sono da mi ho questo anche", "ìò"),
+	newProfile("pt", "o a de que e do da em um uma para com não os as no na é se por mais ao seu também", "ãõ"),
+	newProfile("nl", "de het een en van is dat in te op niet zijn voor met die er ik je ook maar wat", "ĳ"),
+	newProfile("sv", "och i att det som en är på för med av den inte jag har till om var ett men", "åäö"),
+	newProfile("da", "og i at det er en den til på med af ikke som jeg for de har hvad meget nu efter", "æøå"),
+	newProfile("no", "og i det er en til på som at med for ikke jeg av har den de hva mye nå etter", "æøå"),
+	newProfile("fi", "ja on ei se että oli hän ovat mutta kun tai myös kuin tämä olla minä sinä mitä", "äö"),
+	newProfile("pl", "i w nie na się z że do to jest jak o po co ale od są czy", "ąćęłńśźż"),
+	newProfile("cs", "a v se na je že to s z do o ale jako pro by si jsem jsou není", "ěřů"),
+	newProfile("sk", "a v sa na je že to s z do o ale ako pre by si som sú nie", "ľĺŕô"),
+	newProfile("sl", "in je v se na da za so z ki pa to ne bi tudi", ""),
+	newProfile("hr", "i je u se na da za su s od to ne kao što biti", "ćđ"),
+	newProfile("hu", "a az és hogy nem is egy van meg de ez ki volt csak már", "őű"),
+	newProfile("ro", "și de la în a cu nu o că pe este un din care sunt", "ăîșțşţ"),
+	newProfile("tr", "ve bir bu da de için ile ne çok daha gibi olarak ama var değil", "ğı"),
+	newProfile("az", "və bir bu da üçün ilə ki olan çox deyil", "ə"),
+	newProfile("id", "yang dan di ini itu dengan untuk tidak dari dalam akan pada ke ada bisa saja karena sudah", ""),
+	newProfile("ms", "yang dan di ini itu dengan untuk tidak dari dalam akan pada ke ada boleh sahaja kerana daripada", ""),
+	newProfile("vi", "và của là có không được cho trong một những các người này đã với", "ăđơưạảấầẩẫậắằẳẵặẹẻẽếềểễệỉịọỏốồổỗộớờởỡợụủứừửữựỳỵỷỹ"),
+	newProfile("tl", "ang ng sa na mga at ay si ko hindi ako ito siya", ""),
+	newProfile("sw", "na ya wa kwa ni za la katika kuwa hii lakini", ""),
+	newProfile("ca", "el la de i que a en els les per un una amb no és del al", "·"),
+	newProfile("eu", "eta da ez du ere bat baina hau dira zen izan", ""),
+	newProfile("et", "ja on ei et see oli ta kui ka aga nii mis", "õ"),
+	newProfile("lv", "un ir ar ka no uz par to bet kas vai", "āēģīķļņū"),
+	newProfile("lt", "ir yra kad su tai į iš bet kaip jo ne už", "ėįųū"),
+	newProfile("sq", "dhe të në një për me është nga që si", "ë"),
+	newProfile("is", "og að er í á sem um við ekki það en", "ðþ"),
+	newProfile("ga", "agus an na is ar ag le go ní sé bhí", ""),
+	newProfile("cy", "a y yn ac i o mae yr ei ar gyda", "ŵŷ"),
+	newProfile("af", "die en van is in het nie dat op vir met", ""),
+	newProfile("eo", "la kaj de en estas al por ke ne mi vi li", "ĉĝĥĵŝŭ"),
+	newProfile("la", "et in est non ad cum quod ut sed qui esse", ""),
+	newProfile("mt", "il u ta li fil ma hija huwa għal", "ċġħ"),
+}
+
+var cyrillicProfiles = []*profile{
+	newProfile("ru", "и в не на что это я он с как по но из у за то", "ыэё"),
+	newProfile("uk", "і в не на що це я він з як та але до від", "іїєґ"),
+	newProfile("be", "і ў не на што гэта я ён з як але да", "ўі"),
+	newProfile("bg", "и в не на че това аз той с като е да се за", "ъ"),
+	newProfile("sr", "и у не на да је се за од то што", "ђћџ"),
+	newProfile("mk", "и во не на дека е се за од што", "ѓќѕ"),
+	newProfile("kk", "және мен бұл бір да ол", "әғқңұһ"),
+	newProfile("mn", "нь бол ба энэ юм байна", "өү"),
+	newProfile("tg", "ва дар ба аз ки ин бо", "ӣӯҳҷ"),
+}
+
+var arabicProfiles = []*profile{
+	newProfile("ar", "في من على أن إلى هذا التي الذي عن مع كان", "ة"),
+	newProfile("fa", "و در به از که این را با است می", "پچژگ"),
+	newProfile("ur", "کے کی میں ہے اور سے کو نے یہ پر", "ٹڈڑںےھ"),
+	newProfile("ps", "او د په چې دا له", "ټډړږښګڼ"),
+	newProfile("sd", "۽ جي ۾ کي تي", "ڄڃڇڊڌڍڏڙڦ"),
+	newProfile("ug", "ۋە بىلەن بۇ بىر", "ەۆۇۈۋې"),
+}
+
+// scripts is in the order of priority for the letters belonging to
+// more than one script.
+var scripts = []*script{
+	{unicode.Hiragana, "ja", nil},
+	{unicode.Katakana, "ja", nil},
+	{unicode.Hangul, "ko", nil},
+	{unicode.Han, "zh", nil},
+	{unicode.Thai, "th", nil},
+	{unicode.Lao, "lo", nil},
+	{unicode.Khmer, "km", nil},
+	{unicode.Myanmar, "my", nil},
+	{unicode.Georgian, "ka", nil},
+	{unicode.Armenian, "hy", nil},
+	{unicode.Hebrew, "he", nil},
+	{unicode.Greek, "el", nil},
+	{unicode.Devanagari, "hi", nil},
+	{unicode.Bengali, "bn", nil},
+	{unicode.Gurmukhi, "pa", nil},
+	{unicode.Gujarati, "gu", nil},
+	{unicode.Oriya, "or", nil},
+	{unicode.Tamil, "ta", nil},
+	{unicode.Telugu, "te", nil},
+	{unicode.Kannada, "kn", nil},
+	{unicode.Malayalam, "ml", nil},
+	{unicode.Sinhala, "si", nil},
+	{unicode.Tibetan, "bo", nil},
+	{unicode.Ethiopic, "am", nil},
+	{unicode.Thaana, "dv", nil},
+	{unicode.Canadian_Aboriginal, "iu", nil},
+	{unicode.Yi, "ii", nil},
+	{unicode.Cyrillic, "ru", cyrillicProfiles},
+	{unicode.Arabic, "ar", arabicProfiles},
+	{unicode.Latin, "", latinProfiles},
+}
+
+func scriptOf(r rune) *script {
+	for _, s := range scripts {
+		if unicode.Is(s.table, r) {
+			return s
+		}
+	}
+	return nil
+}
+
+// Detect detects the language of text without network, by the
+// scripts of its letters, and by the common words and letters of the
+// languages sharing a script. ok is false if text has no letters or
+// the language cannot be told.
+func Detect(text string) (d Detection, ok bool) {
+	counts := map[*script]int{}
+	total := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		if s := scriptOf(r); s != nil {
+			counts[s]++
+			total++
+		}
+	}
+	if total == 0 {
+		return Detection{}, false
+	}
+
+	// Kanji are a part of Japanese with kana, and Hanja of Korean with
+	// Hangul.
+	han, hira, kata, hangul := scripts[3], scripts[0], scripts[1], scripts[2]
+	if counts[hira]+counts[kata] > 0 {
+		counts[hira] += counts[kata] + counts[han]
+		delete(counts, kata)
+		delete(counts, han)
+	} else if counts[hangul] > 0 {
+		counts[hangul] += counts[han]
+		delete(counts, han)
+	}
+
+	var best *script
+	for _, s := range scripts {
+		if counts[s] > 0 && (best == nil || counts[s] > counts[best]) {
+			best = s
+		}
+	}
+	code, conf := best.code, float64(counts[best])/float64(total)
+	if best.profiles != nil {
+		c, pconf, found := detectProfile(text, best.profiles)
+		if found {
+			code, conf = c, conf*pconf
+		} else if code == "" {
+			return Detection{}, false
+		} else {
+			conf *= 0.5
+		}
+	}
+	name, ok := iso639map[code]
+	if !ok {
+		return Detection{}, false
+	}
+	return Detection{Code: code, Name: name, Confidence: conf}, true
+}
+
+// detectProfile returns the language of the profile scoring best for
+// the words of text, and its share of the scores of the best two.
+func detectProfile(text string, profiles []*profile) (code string, conf float64, ok bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.Is(unicode.Mn, r) && r != '·'
+	})
+	var best *profile
+	var first, second float64
+	for _, p := range profiles {
+		n := p.score(words)
+		switch {
+		case n > first:
+			best, first, second = p, n, first
+		case n > second:
+			second = n
+		}
+	}
+	if best == nil {
+		return "", 0, false
+	}
+	return best.code, first / (first + second), true
+}
@@ -0,0 +1,61 @@
+package tran
+
+import "testing"
+
+type DetectTest struct {
+	in   string
+	code string
+}
+
+var detecttests = []DetectTest{
+	0:  {"", ""},
+	1:  {"123 !?", ""},
+	2:  {"The quick brown fox jumps over the lazy dog, and it was fun.", "en"},
+	3:  {"Le chat est sur la table et il dort dans la cuisine.", "fr"},
+	4:  {"Die Katze ist nicht auf dem Tisch, sie schläft in der Küche.", "de"},
+	5:  {"El gato está en la mesa y duerme en la cocina con los niños.", "es"},
+	6:  {"Il gatto è sul tavolo e non dorme nella cucina della casa.", "it"},
+	7:  {"O gato não está na mesa e dorme em uma cozinha com as crianças.", "pt"},
+	8:  {"De kat ligt op de tafel en het is niet in de keuken.", "nl"},
+	9:  {"Katten är på bordet och den sover i köket med barnen.", "sv"},
+	10: {"Kot jest na stole i nie śpi w kuchni, ale się bawi.", "pl"},
+	11: {"Kočka je na stole a spí v kuchyni, ale není to pravda.", "cs"},
+	12: {"Kedi masanın üstünde ve mutfakta uyuyor, çok güzel bir gün.", "tr"},
+	13: {"Con mèo đang ngủ trong nhà bếp và nó không muốn ăn.", "vi"},
+	14: {"Kucing itu ada di atas meja dan tidak tidur di dapur karena sudah pagi.", "id"},
+	15: {"Kissa on pöydällä ja se nukkuu keittiössä, mutta ei tänään.", "fi"},
+	16: {"A macska az asztalon van, és nem alszik a konyhában.", "hu"},
+	17: {"Pisica este pe masă și doarme în bucătărie cu copiii.", "ro"},
+	18: {"猫はテーブルの上で寝ています。", "ja"},
+	19: {"고양이가 테이블 위에서 자고 있습니다.", "ko"},
+	20: {"猫在桌子上睡觉。", "zh"},
+	21: {"Кошка спит на столе, и это очень мило.", "ru"},
+	22: {"Кіт спить на столі, і це дуже мило.", "uk"},
+	23: {"Котката спи на масата и това е много мило.", "bg"},
+	24: {"Мачка спава на столу и то је веома слатко, што ђе.", "sr"},
+	25: {"Η γάτα κοιμάται στο τραπέζι.", "el"},
+	26: {"החתול ישן על השולחן.", "he"},
+	27: {"القطة نائمة على الطاولة في المطبخ.", "ar"},
+	28: {"گربه روی میز در آشپزخانه خوابیده است.", "fa"},
+	29: {"بلی میز پر سو رہی ہے اور یہ بہت پیاری ہے۔", "ur"},
+	30: {"बिल्ली मेज़ पर सो रही है।", "hi"},
+	31: {"แมวนอนอยู่บนโต๊ะ", "th"},
+	32: {"კატა მაგიდაზე სძინავს.", "ka"},
+	33: {"Կատուն քնած է սեղանի վրա։", "hy"},
+	34: {"பூனை மேசையில் தூங்குகிறது.", "ta"},
+	35: {"Xyzzy plugh", ""},
+}
+
+func TestDetect(t *testing.T) {
+	for i, tt := range detecttests {
+		d, ok := Detect(tt.in)
+		if d.Code != tt.code || ok != (tt.code != "") {
+			t.Errorf("#%d Detect(%q) = (%+v, %v), want: %q", i, tt.in, d, ok, tt.code)
+			continue
+		}
+		if ok && (d.Name != iso639map[tt.code] || d.Confidence <= 0 || d.Confidence > 1) {
+			t.Errorf("#%d Detect(%q) = %+v, want: name %q, confidence in (0, 1]",
+				i, tt.in, d, iso639map[tt.code])
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package tran
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnsupportedLanguage means the source or target language is
+	// not supported by the backend.
+	ErrUnsupportedLanguage = errors.New("unsupported language")
+
+	// ErrQuotaExceeded means the backend refused the request since
+	// it was invoked too many times.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// ErrInvalidResponse means the response of the backend could not
+	// be understood.
+	ErrInvalidResponse = errors.New("invalid response")
+)
+
+// APIError is returned when the backend responds with a failure,
+// such as a TransData whose code is not 200.
+type APIError struct {
+	Code    int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Is reports whether e means target, one of the sentinel errors of
+// this package.
+func (e *APIError) Is(target error) bool {
+	msg := strings.ToLower(e.Message)
+	switch target {
+	case ErrUnsupportedLanguage:
+		return strings.Contains(msg, "invalid argument") ||
+			strings.Contains(msg, "not supported")
+	case ErrQuotaExceeded:
+		return e.Code == http.StatusTooManyRequests ||
+			strings.Contains(msg, "too many times")
+	}
+	return false
+}
+
+// HTTPError is returned when the HTTP status of the response is
+// not 2xx.
+type HTTPError struct {
+	StatusCode int
+
+	// RetryAfter is the wait requested by the Retry-After header,
+	// or zero.
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Is reports whether e means target, one of the sentinel errors of
+// this package.
+func (e *HTTPError) Is(target error) bool {
+	return target == ErrQuotaExceeded &&
+		e.StatusCode == http.StatusTooManyRequests
+}
+
+func invalidResponse(err error) error {
+	return fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+}
@@ -0,0 +1,64 @@
+package tran
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/y-bash/go-tran/trantest"
+)
+
+type ErrorsIsTest struct {
+	err    error
+	target error
+	is     bool
+}
+
+var errorsistests = []ErrorsIsTest{
+	0: {&APIError{400, "Invalid argument: target"}, ErrUnsupportedLanguage, true},
+	1: {&APIError{400, "Invalid argument: target"}, ErrQuotaExceeded, false},
+	2: {&APIError{400, "Service invoked too many times for one day"}, ErrQuotaExceeded, true},
+	3: {&APIError{500, "Internal error"}, ErrUnsupportedLanguage, false},
+	4: {&HTTPError{StatusCode: 429}, ErrQuotaExceeded, true},
+	5: {&HTTPError{StatusCode: 503}, ErrQuotaExceeded, false},
+	6: {fmt.Errorf("chunk 3: %w", &APIError{400, "Invalid argument: source"}), ErrUnsupportedLanguage, true},
+	7: {invalidResponse(errors.New("unexpected EOF")), ErrInvalidResponse, true},
+}
+
+func TestErrorsIs(t *testing.T) {
+	for i, tt := range errorsistests {
+		if is := errors.Is(tt.err, tt.target); is != tt.is {
+			t.Errorf("#%d errors.Is(%v, %v) = %v, want: %v",
+				i, tt.err, tt.target, is, tt.is)
+		}
+	}
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	s := newAPIServer()
+	defer s.Close()
+	c := NewClient(Endpoint(s.URL), nil)
+
+	_, err := c.Translate("Cat", "", "xx")
+	var ae *APIError
+	if !errors.As(err, &ae) || ae.Code != 400 || ae.Message != "Invalid argument: target" {
+		t.Errorf("have error: %#v, want: *APIError{400, %q}", err, "Invalid argument: target")
+	}
+	if !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("errors.Is(%v, ErrUnsupportedLanguage) = false, want: true", err)
+	}
+
+	s.Script(trantest.Reply{Body: "<html>Moved</html>"})
+	_, err = c.Translate("Cat", "", "ja")
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Errorf("errors.Is(%v, ErrInvalidResponse) = false, want: true", err)
+	}
+
+	s.Script(trantest.Reply{Status: http.StatusServiceUnavailable})
+	_, err = c.Translate("Cat", "", "ja")
+	var he *HTTPError
+	if !errors.As(err, &he) || he.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("have error: %#v, want: *HTTPError{503}", err)
+	}
+}
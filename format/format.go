@@ -0,0 +1,287 @@
+// Package format splits documents of various formats into the segments
+// of text to translate, and reassembles them with the translations,
+// leaving the structure of the documents untouched.
+package format
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/y-bash/go-tran"
+)
+
+// Document is a parsed document.
+type Document interface {
+	// Segments returns the texts to translate in the order of the
+	// document.
+	Segments() []string
+
+	// Render writes the document to w with each segment replaced by
+	// the translation of the same index. It returns a
+	// *tran.PlaceholderError without writing anything if the masks of a
+	// segment are lost in its translation.
+	Render(w io.Writer, translated []string) error
+
+	// Unmask returns s, the segment i or its translation, with the
+	// pieces masked in it restored to be read, but not escaped for the
+	// document. It returns a *tran.PlaceholderError if the masks are
+	// lost in s.
+	Unmask(i int, s string) (string, error)
+}
+
+// LanguageDocument is implemented by the Documents declaring the
+// languages of their translations, such as XLIFF.
+type LanguageDocument interface {
+	Document
+
+	// Languages returns the source and the target language declared
+	// by the document, which are empty if it does not declare them.
+	Languages() (source, target string)
+}
+
+// Options holds the languages of the translation, which some formats
+// record in the documents, and the options of the formats. A nil
+// Options means the languages are unknown.
+type Options struct {
+	Source string // empty if it is detected by the translator
+	Target string
+
+	// MergeCues merges the cues of subtitles continuing a sentence to
+	// translate them together.
+	MergeCues bool
+
+	// Force translates the messages of catalogs already translated.
+	Force bool
+
+	// Include and Exclude select the values of structured files, such
+	// as JSON, to translate by the patterns of their keys. See
+	// ParseJSON for the patterns. All the values are translated if
+	// Include is empty.
+	Include, Exclude []string
+}
+
+// Parser parses src into a Document to be translated according to opts.
+type Parser func(src []byte, opts *Options) (Document, error)
+
+var parsers = map[string]Parser{}
+
+var extensions = map[string]string{}
+
+// register adds a format with its file extensions.
+func register(name string, p Parser, exts ...string) {
+	parsers[name] = p
+	for _, ext := range exts {
+		extensions[ext] = name
+	}
+}
+
+// Lookup returns the Parser of the format name.
+func Lookup(name string) (p Parser, ok bool) {
+	p, ok = parsers[strings.ToLower(name)]
+	return
+}
+
+// Names returns the names of the formats in alphabetical order.
+func Names() []string {
+	a := make([]string, 0, len(parsers))
+	for name := range parsers {
+		a = append(a, name)
+	}
+	sort.Strings(a)
+	return a
+}
+
+// ByExtension returns the name of the format of the file at path,
+// according to its extension.
+func ByExtension(path string) (name string, ok bool) {
+	name, ok = extensions[strings.ToLower(filepath.Ext(path))]
+	return
+}
+
+// ErrSegmentCount is returned by Render if the number of the
+// translations differs from the number of the segments.
+var ErrSegmentCount = errors.New("number of translations differs from segments")
+
+// segment is a text to translate, whose protected pieces are masked.
+// The masks are restored in the translation after escape, or else
+// before it if they are inner, such as the placeholders of a quoted
+// string.
+type segment struct {
+	text   string
+	masks  []string
+	inner  bool
+	escape func(string) string // applied to the translation if not nil
+}
+
+// part is a piece of a document, which is either kept verbatim or
+// the segment of the index seg.
+type part struct {
+	text string
+	seg  int // -1 for verbatim
+}
+
+// doc is a Document made of parts in order. The verbatim text and the
+// masks may embed other segments by their refs, such as a translated
+// attribute in a masked tag.
+type doc struct {
+	parts []part
+	segs  []segment
+}
+
+// verbatim appends s as it is.
+func (d *doc) verbatim(s string) {
+	if s == "" {
+		return
+	}
+	if n := len(d.parts); n > 0 && d.parts[n-1].seg < 0 {
+		d.parts[n-1].text += s
+		return
+	}
+	d.parts = append(d.parts, part{text: s, seg: -1})
+}
+
+// segment appends s to be translated, with the pieces matched by
+// protect masked. The leading and trailing spaces of s, and s without
+// any word to translate, are kept verbatim.
+func (d *doc) segment(s string, protect *regexp.Regexp) {
+	var masks []string
+	if protect != nil {
+		s, masks = tran.Mask(s, protect)
+	}
+	d.verbatim(d.maskedSegment(s, masks, nil))
+}
+
+// maskedSegment appends s with masks to be translated, and returns the
+// text to append after it. escape is applied to the translation.
+func (d *doc) maskedSegment(s string, masks []string, escape func(string) string) (trail string) {
+	body := strings.TrimSpace(s)
+	start := strings.Index(s, body)
+	lead, trail := s[:start], s[start+len(body):]
+	if !hasWords(tran.StripMasks(body)) {
+		s, _ = tran.Unmask(s, masks) // not translated, with all the masks
+		d.verbatim(s)
+		return ""
+	}
+	d.verbatim(lead)
+	d.segs = append(d.segs, segment{text: body, masks: masks, escape: escape})
+	d.parts = append(d.parts, part{seg: len(d.segs) - 1})
+	return trail
+}
+
+// ref returns a reference to s with masks to be translated, to be
+// embedded in the verbatim text or a mask. s without any word is
+// returned unmasked and escaped.
+func (d *doc) ref(s string, masks []string, escape func(string) string) string {
+	if !hasWords(tran.StripMasks(s)) {
+		if escape != nil {
+			s = escape(s)
+		}
+		s, _ = tran.Unmask(s, masks) // not translated, with all the masks
+		return s
+	}
+	return d.add(segment{text: s, masks: masks, escape: escape})
+}
+
+// add appends seg, and returns the reference to it.
+func (d *doc) add(seg segment) string {
+	d.segs = append(d.segs, seg)
+	return fmt.Sprintf("\x00%d\x00", len(d.segs)-1)
+}
+
+// quoted returns a reference to s to be translated, with the pieces
+// matched by protect masked, and the translation quoted by quote with
+// the leading and trailing spaces of s. s without any word is quoted
+// as it is.
+func (d *doc) quoted(s string, protect *regexp.Regexp, quote func(string) string) string {
+	body := strings.TrimSpace(s)
+	start := strings.Index(s, body)
+	lead, trail := s[:start], s[start+len(body):]
+	masked, masks := tran.Mask(body, protect)
+	if !hasWords(tran.StripMasks(masked)) {
+		return quote(s)
+	}
+	return d.add(segment{text: masked, masks: masks, inner: true, escape: func(t string) string {
+		return quote(lead + t + trail)
+	}})
+}
+
+var refPattern = regexp.MustCompile("\x00(\\d+)\x00")
+
+func (d *doc) Segments() []string {
+	a := make([]string, len(d.segs))
+	for i, seg := range d.segs {
+		a[i] = seg.text
+	}
+	return a
+}
+
+func (d *doc) Render(w io.Writer, translated []string) error {
+	if len(translated) != len(d.segs) {
+		return ErrSegmentCount
+	}
+	outs := make([]string, len(d.segs))
+	for i, seg := range d.segs {
+		s := translated[i]
+		var err error
+		if seg.inner {
+			s, err = tran.Unmask(s, seg.masks)
+		}
+		if seg.escape != nil {
+			s = seg.escape(s)
+		}
+		if !seg.inner && err == nil {
+			s, err = tran.Unmask(s, seg.masks)
+		}
+		if err != nil {
+			return fmt.Errorf("%q: %w", seg.text, err)
+		}
+		outs[i] = s
+	}
+	var expand func(s string) string
+	expand = func(s string) string {
+		return refPattern.ReplaceAllStringFunc(s, func(m string) string {
+			i, _ := strconv.Atoi(m[1 : len(m)-1])
+			return expand(outs[i])
+		})
+	}
+	var sb strings.Builder
+	for _, p := range d.parts {
+		if p.seg < 0 {
+			sb.WriteString(p.text)
+		} else {
+			sb.WriteString(outs[p.seg])
+		}
+	}
+	_, err := io.WriteString(w, expand(sb.String()))
+	return err
+}
+
+// Unmask restores the segments embedded in the masks as their texts.
+func (d *doc) Unmask(i int, s string) (string, error) {
+	s, err := tran.Unmask(s, d.segs[i].masks)
+	if err != nil {
+		return "", err
+	}
+	return refPattern.ReplaceAllStringFunc(s, func(m string) string {
+		j, _ := strconv.Atoi(m[1 : len(m)-1])
+		t, _ := d.Unmask(j, d.segs[j].text)
+		return t
+	}), nil
+}
+
+// hasWords reports whether s has a letter to translate.
+func hasWords(s string) bool {
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
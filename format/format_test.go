@@ -0,0 +1,136 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/y-bash/go-tran"
+)
+
+// render parses src by parse, and renders it with the segments
+// translated by upper-casing them.
+func render(t *testing.T, parse Parser, src string, opts *Options) (segs []string, out string) {
+	d, err := parse([]byte(src), opts)
+	if err != nil {
+		t.Fatalf("parse(%q) have error: %s", src, err)
+	}
+	segs = d.Segments()
+	translated := make([]string, len(segs))
+	for i, s := range segs {
+		translated[i] = strings.ToUpper(s)
+	}
+	var buf bytes.Buffer
+	if err := d.Render(&buf, translated); err != nil {
+		t.Fatalf("Render(%q) have error: %s", src, err)
+	}
+	return segs, buf.String()
+}
+
+type RenderLostTest struct {
+	parse      Parser
+	in         string
+	translated []string
+}
+
+var renderlosttests = []RenderLostTest{
+	0: {ParseMarkdown, "Run `go build` now\n", []string{"RUN NOW"}},
+	1: {ParsePO, "msgid \"Hello, %s!\"\nmsgstr \"\"\n", []string{"HELLO!"}},
+	2: {ParseHTML, "<p>Hello <b>big</b> world.</p>", []string{"HELLO ⟦1⟧BIG WORLD."}},
+}
+
+func TestRender_PlaceholderLost(t *testing.T) {
+	for i, tt := range renderlosttests {
+		d, err := tt.parse([]byte(tt.in), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sb strings.Builder
+		err = d.Render(&sb, tt.translated)
+		var pe *tran.PlaceholderError
+		if !errors.As(err, &pe) || sb.Len() != 0 {
+			t.Errorf("#%d Render() = (%q, %v), want: PlaceholderError without output", i, sb.String(), err)
+		}
+	}
+}
+
+func TestRender_SegmentCount(t *testing.T) {
+	d, _ := ParseMarkdown([]byte("a\n\nb\n"), nil)
+	if err := d.Render(&bytes.Buffer{}, []string{"A"}); err != ErrSegmentCount {
+		t.Errorf("have error: %v, want error: %v", err, ErrSegmentCount)
+	}
+}
+
+type ByExtensionTest struct {
+	path string
+	name string
+	ok   bool
+}
+
+var byextensiontests = []ByExtensionTest{
+	0:  {"README.md", "md", true},
+	1:  {"doc/guide.Markdown", "md", true},
+	2:  {"main.go", "", false},
+	3:  {"LICENSE", "", false},
+	4:  {"site/index.HTML", "html", true},
+	5:  {"page.htm", "html", true},
+	6:  {"movie.en.srt", "srt", true},
+	7:  {"talk.vtt", "vtt", true},
+	8:  {"locale/ja/app.po", "po", true},
+	9:  {"app.pot", "po", true},
+	10: {"locales/en.json", "json", true},
+	11: {"config/locales/en.yml", "yaml", true},
+	12: {"i18n/active.en.toml", "toml", true},
+	13: {"vendor/ja.xlf", "xliff", true},
+	14: {"messages.xliff", "xliff", true},
+}
+
+func TestByExtension(t *testing.T) {
+	for i, tt := range byextensiontests {
+		name, ok := ByExtension(tt.path)
+		if name != tt.name || ok != tt.ok {
+			t.Errorf("#%d ByExtension(%q) = (%q, %v), want: (%q, %v)",
+				i, tt.path, name, ok, tt.name, tt.ok)
+		}
+	}
+}
+
+type DocumentUnmaskTest struct {
+	parse      Parser
+	in         string
+	i          int
+	source     string
+	translated string // of the segment i
+	out        string
+}
+
+var documentunmasktests = []DocumentUnmaskTest{
+	0: {ParseMarkdown, "Use `go` and [the docs](http://x) now.\n", 0,
+		"Use `go` and [the docs](http://x) now.", "Utilisez ⟦0⟧ et [la doc⟦1⟧.", "Utilisez `go` et [la doc](http://x)."},
+	1: {ParsePO, "msgid \"Hello, %s <b>\\\"x\\\"</b>!\"\nmsgstr \"\"\n", 0,
+		`Hello, %s <b>"x"</b>!`, "⟦0⟧ ⟦1⟧\"x\"⟦2⟧ !", `%s <b>"x"</b> !`},
+	2: {ParseHTML, `<p>Hello <img src=a.png alt="A cat"> world.</p>`, 1,
+		`Hello <img src=a.png alt="A cat"> world.`, "Bonjour ⟦0⟧ monde.", `Bonjour <img src=a.png alt="A cat"> monde.`},
+	3: {ParseSubtitles, "1\n00:00:01,000 --> 00:00:02,000\n<i>Hello</i> world.\n", 0,
+		"<i>Hello</i> world.", "⟦0⟧Bonjour⟦1⟧ monde.", "<i>Bonjour</i> monde."},
+}
+
+func TestDocument_Unmask(t *testing.T) {
+	for i, tt := range documentunmasktests {
+		d, err := tt.parse([]byte(tt.in), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s, err := d.Unmask(tt.i, d.Segments()[tt.i]); err != nil || s != tt.source {
+			t.Errorf("#%d Unmask(source) = (%q, %v), want: (%q, nil)", i, s, err, tt.source)
+		}
+		if s, err := d.Unmask(tt.i, tt.translated); err != nil || s != tt.out {
+			t.Errorf("#%d Unmask(%q) = (%q, %v), want: (%q, nil)", i, tt.translated, s, err, tt.out)
+		}
+		var pe *tran.PlaceholderError
+		if _, err := d.Unmask(tt.i, "lost"); !errors.As(err, &pe) {
+			t.Errorf("#%d Unmask(lost) have error: %v, want: PlaceholderError", i, err)
+		}
+	}
+}
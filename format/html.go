@@ -0,0 +1,475 @@
+package format
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/y-bash/go-tran"
+)
+
+func init() {
+	register("html", ParseHTML, ".html", ".htm", ".xhtml")
+}
+
+var (
+	// htmlInline is the elements which continue the sentences around
+	// them. They are masked in the segments of the sentences.
+	htmlInline = setOf("a abbr b bdi bdo big br cite data del dfn em font i img ins label " +
+		"mark q rp rt ruby s small span strong sub sup time tt u wbr")
+
+	// htmlCode is the inline elements whose contents are not translated.
+	htmlCode = setOf("code kbd samp var")
+
+	// htmlRaw is the elements whose contents are not translated.
+	htmlRaw = setOf("script style pre svg math template")
+
+	htmlVoid = setOf("area base br col embed hr img input link meta param source track wbr")
+
+	// htmlAttrs is the attributes translated.
+	htmlAttrs = setOf("alt title placeholder")
+)
+
+// htmlEntity matches the character references kept untranslated.
+var htmlEntity = regexp.MustCompile(`&(#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z][a-zA-Z0-9]*);`)
+
+var escapeText = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+
+func setOf(names string) map[string]bool {
+	m := map[string]bool{}
+	for _, s := range strings.Fields(names) {
+		m[s] = true
+	}
+	return m
+}
+
+// ParseHTML parses an HTML document. Its text and the alt, title and
+// placeholder attributes are translated, while the markup is kept as
+// it is. The sentences are translated as a whole with their inline
+// elements masked. The elements with translate="no", or with a lang
+// attribute other than the source language, are not translated, nor
+// are code, pre, script and style. The lang attribute of the html
+// element is set to the target language.
+func ParseHTML(src []byte, opts *Options) (Document, error) {
+	p := &htmlParser{sc: htmlScanner{src: string(src)}}
+	if opts != nil {
+		p.opts = *opts
+	}
+	p.source = primaryLang(p.opts.Source)
+	p.parse()
+	return &p.doc, nil
+}
+
+// primaryLang returns the primary language subtag of a language tag,
+// such as "en" for "en-US".
+func primaryLang(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+type htmlParser struct {
+	doc    doc
+	sc     htmlScanner
+	opts   Options
+	source string
+
+	run   strings.Builder // the masked text of the current sentences
+	masks []string
+}
+
+func (p *htmlParser) parse() {
+	for {
+		t, ok := p.sc.next()
+		if !ok {
+			break
+		}
+		switch t.kind {
+		case htmlText:
+			p.text(t.raw)
+		case htmlStartTag:
+			p.startTag(t)
+		case htmlEndTag:
+			if htmlInline[t.name] {
+				p.inline(t.raw)
+			} else {
+				p.flush()
+				p.doc.verbatim(t.raw)
+			}
+		default:
+			p.flush()
+			p.doc.verbatim(t.raw)
+		}
+	}
+	p.flush()
+}
+
+// text adds raw text to the current sentences.
+func (p *htmlParser) text(raw string) {
+	p.run.WriteString(htmlEntity.ReplaceAllStringFunc(raw, func(m string) string {
+		p.masks = append(p.masks, m)
+		return tran.MaskToken(len(p.masks) - 1)
+	}))
+}
+
+// inline adds raw markup to the current sentences as a mask.
+func (p *htmlParser) inline(raw string) {
+	p.masks = append(p.masks, raw)
+	p.run.WriteString(tran.MaskToken(len(p.masks) - 1))
+}
+
+// flush ends the current sentences.
+func (p *htmlParser) flush() {
+	p.doc.verbatim(p.doc.maskedSegment(p.run.String(), p.masks, escapeText))
+	p.run.Reset()
+	p.masks = nil
+}
+
+func (p *htmlParser) startTag(t htmlToken) {
+	if t.name == "html" {
+		p.flush()
+		p.doc.verbatim(p.htmlTag(t))
+		return
+	}
+	skip := htmlRaw[t.name] || htmlCode[t.name]
+	if v, ok := t.attr("translate"); ok && strings.EqualFold(v, "no") {
+		skip = true
+	}
+	if v, ok := t.attr("lang"); ok && p.source != "" && primaryLang(v) != p.source {
+		skip = true
+	}
+	if skip {
+		raw := t.raw
+		if !t.selfClosing && !htmlVoid[t.name] {
+			raw += p.sc.skip(t.name)
+		}
+		if htmlInline[t.name] || htmlCode[t.name] {
+			p.inline(raw)
+		} else {
+			p.flush()
+			p.doc.verbatim(raw)
+		}
+		return
+	}
+	raw := p.translateAttrs(t)
+	if htmlInline[t.name] {
+		p.inline(raw)
+		return
+	}
+	p.flush()
+	p.doc.verbatim(raw)
+}
+
+// htmlTag returns the html tag with its lang attribute set to the
+// target language, after taking the source language from it.
+func (p *htmlParser) htmlTag(t htmlToken) string {
+	a, ok := t.attrOf("lang")
+	if ok && p.source == "" {
+		p.source = primaryLang(t.raw[a.start:a.end])
+	}
+	if p.opts.Target == "" {
+		return t.raw
+	}
+	return t.withAttr("lang", p.opts.Target)
+}
+
+// translateAttrs returns the raw tag with the values of the translated
+// attributes replaced by the references to their segments, and the
+// lang attribute set to the target language.
+func (p *htmlParser) translateAttrs(t htmlToken) string {
+	var sb strings.Builder
+	last := 0
+	for _, a := range t.attrs {
+		if !a.hasValue {
+			continue
+		}
+		raw := t.raw[a.start:a.end]
+		var s string
+		switch {
+		case a.name == "lang" && p.opts.Target != "":
+			s = p.opts.Target
+		case htmlAttrs[a.name]:
+			value := html.UnescapeString(raw)
+			if !hasWords(value) {
+				continue
+			}
+			s = p.doc.ref(value, nil, escapeAttr(a.quote))
+			if a.quote == 0 {
+				s = `"` + s + `"`
+			}
+		default:
+			continue
+		}
+		sb.WriteString(t.raw[last:a.start])
+		sb.WriteString(s)
+		last = a.end
+	}
+	sb.WriteString(t.raw[last:])
+	return sb.String()
+}
+
+func escapeAttr(quote byte) func(string) string {
+	if quote == '\'' {
+		return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "'", "&#39;").Replace
+	}
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace
+}
+
+const (
+	htmlText = iota
+	htmlStartTag
+	htmlEndTag
+	htmlOther // comments, doctypes and processing instructions
+)
+
+type htmlAttr struct {
+	name       string
+	start, end int // of the value in the raw tag
+	quote      byte
+	hasValue   bool
+}
+
+type htmlToken struct {
+	kind        int
+	raw         string
+	name        string // lower case
+	attrs       []htmlAttr
+	selfClosing bool
+}
+
+func (t *htmlToken) attrOf(name string) (htmlAttr, bool) {
+	for _, a := range t.attrs {
+		if a.name == name {
+			return a, true
+		}
+	}
+	return htmlAttr{}, false
+}
+
+// withAttr returns the raw tag with the attribute name set to value,
+// which is added after the tag name if the tag does not have it.
+func (t *htmlToken) withAttr(name, value string) string {
+	a, ok := t.attrOf(name)
+	if !ok {
+		n := len("<") + len(t.name)
+		return t.raw[:n] + " " + name + `="` + escapeAttr('"')(value) + `"` + t.raw[n:]
+	}
+	if a.quote == 0 {
+		return t.raw[:a.start] + `"` + escapeAttr('"')(value) + `"` + t.raw[a.end:]
+	}
+	return t.raw[:a.start] + escapeAttr(a.quote)(value) + t.raw[a.end:]
+}
+
+func (t *htmlToken) attr(name string) (string, bool) {
+	a, ok := t.attrOf(name)
+	if !ok {
+		return "", false
+	}
+	return html.UnescapeString(t.raw[a.start:a.end]), true
+}
+
+// htmlScanner splits HTML into tokens keeping their raw text.
+type htmlScanner struct {
+	src string
+	i   int
+	raw string // the end tag closing the raw text to scan next
+}
+
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func (sc *htmlScanner) next() (t htmlToken, ok bool) {
+	if sc.i >= len(sc.src) {
+		return t, false
+	}
+	s := sc.src[sc.i:]
+	if sc.raw != "" {
+		n := indexFold(s, sc.raw)
+		if n < 0 {
+			n = len(s)
+		}
+		sc.raw = ""
+		if n > 0 {
+			sc.i += n
+			return htmlToken{kind: htmlOther, raw: s[:n]}, true
+		}
+	}
+	switch {
+	case strings.HasPrefix(s, "<!--"):
+		return sc.until(htmlOther, "-->"), true
+	case strings.HasPrefix(s, "<![CDATA["):
+		return sc.until(htmlOther, "]]>"), true
+	case strings.HasPrefix(s, "<!"), strings.HasPrefix(s, "<?"):
+		return sc.until(htmlOther, ">"), true
+	case len(s) > 2 && s[0] == '<' && s[1] == '/' && isASCIILetter(s[2]):
+		t = sc.until(htmlEndTag, ">")
+		t.name = tagName(t.raw[2:])
+		return t, true
+	case len(s) > 1 && s[0] == '<' && isASCIILetter(s[1]):
+		return sc.startTag(), true
+	}
+	n := 1
+	for n < len(s) {
+		m := strings.IndexByte(s[n:], '<')
+		if m < 0 {
+			n = len(s)
+			break
+		}
+		n += m
+		if n+1 < len(s) && (isASCIILetter(s[n+1]) || strings.IndexByte("/!?", s[n+1]) >= 0) {
+			break
+		}
+		n++
+	}
+	sc.i += n
+	return htmlToken{kind: htmlText, raw: s[:n]}, true
+}
+
+func (sc *htmlScanner) until(kind int, end string) htmlToken {
+	s := sc.src[sc.i:]
+	n := strings.Index(s, end)
+	if n < 0 {
+		n = len(s)
+	} else {
+		n += len(end)
+	}
+	sc.i += n
+	return htmlToken{kind: kind, raw: s[:n]}
+}
+
+func tagName(s string) string {
+	n := 0
+	for n < len(s) && !isSpace(s[n]) && s[n] != '>' && s[n] != '/' {
+		n++
+	}
+	return strings.ToLower(s[:n])
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func (sc *htmlScanner) startTag() htmlToken {
+	s := sc.src[sc.i:]
+	t := htmlToken{kind: htmlStartTag, name: tagName(s[1:])}
+	i := 1 + len(t.name)
+	for i < len(s) {
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		if s[i] == '>' {
+			i++
+			break
+		}
+		if strings.HasPrefix(s[i:], "/>") {
+			t.selfClosing = true
+			i += 2
+			break
+		}
+		if s[i] == '/' {
+			i++
+			continue
+		}
+		j := i
+		for j < len(s) && !isSpace(s[j]) && s[j] != '=' && s[j] != '>' && !strings.HasPrefix(s[j:], "/>") {
+			j++
+		}
+		if j == i {
+			j++ // a stray '='
+		}
+		a := htmlAttr{name: strings.ToLower(s[i:j])}
+		i = j
+		k := i
+		for k < len(s) && isSpace(s[k]) {
+			k++
+		}
+		if k < len(s) && s[k] == '=' {
+			k++
+			for k < len(s) && isSpace(s[k]) {
+				k++
+			}
+			a.hasValue = true
+			if k < len(s) && (s[k] == '"' || s[k] == '\'') {
+				a.quote = s[k]
+				end := strings.IndexByte(s[k+1:], a.quote)
+				if end < 0 {
+					end = len(s) - k - 1
+				}
+				a.start, a.end = k+1, k+1+end
+				i = a.end + 1
+			} else {
+				e := k
+				for e < len(s) && !isSpace(s[e]) && s[e] != '>' {
+					e++
+				}
+				a.start, a.end = k, e
+				i = e
+			}
+		}
+		t.attrs = append(t.attrs, a)
+	}
+	if i > len(s) {
+		i = len(s)
+	}
+	t.raw = s[:i]
+	sc.i += i
+	if t.name == "script" || t.name == "style" {
+		sc.raw = "</" + t.name
+	}
+	return t
+}
+
+// skip returns the raw text up to and including the end tag of the
+// element name, whose start tag has been scanned.
+func (sc *htmlScanner) skip(name string) string {
+	start := sc.i
+	depth := 1
+	for depth > 0 {
+		t, ok := sc.next()
+		if !ok {
+			break
+		}
+		switch {
+		case t.kind == htmlStartTag && t.name == name && !t.selfClosing:
+			depth++
+		case t.kind == htmlEndTag && t.name == name:
+			depth--
+		}
+	}
+	return sc.src[start:sc.i]
+}
+
+// indexFold is like strings.Index, but ignores the case of ASCII
+// letters.
+// The offset is of s itself, whatever the other characters are.
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if equalFoldASCII(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// equalFoldASCII reports whether a and b of the same length are equal
+// ignoring the case of ASCII letters.
+func equalFoldASCII(a, b string) bool {
+	for i := 0; i < len(a); i++ {
+		if lowerASCII(a[i]) != lowerASCII(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,63 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type HTMLTest struct {
+	in   string
+	opts *Options
+	segs []string
+	out  string
+}
+
+var htmltests = []HTMLTest{
+	0: {"", nil, nil, ""},
+	1: {"<p>Hello <b>big</b> world.</p>\n", nil,
+		[]string{"Hello ⟦0⟧big⟦1⟧ world."},
+		"<p>HELLO <b>BIG</b> WORLD.</p>\n"},
+	2: {`<img src="a.png" alt="A cat"><input placeholder='Your name' title=Search>`, nil,
+		[]string{"A cat", "Your name", "Search"},
+		`<img src="a.png" alt="A CAT"><input placeholder='YOUR NAME' title="SEARCH">`},
+	3: {`<p translate="no">Keep <b>this</b></p><p>Translate</p>`, nil,
+		[]string{"Translate"},
+		`<p translate="no">Keep <b>this</b></p><p>TRANSLATE</p>`},
+	4: {`<p>Hello <span lang="fr">bonjour</span> <i lang="en">you</i></p>`, &Options{Source: "en-US", Target: "ja"},
+		[]string{"Hello ⟦0⟧ ⟦1⟧you⟦2⟧"},
+		`<p>HELLO <span lang="fr">bonjour</span> <i lang="ja">YOU</i></p>`},
+	5: {"<p>Run <code>go build</code> now</p><pre>as is</pre><script>if (a<b) x()</script><style>p{}</style>", nil,
+		[]string{"Run ⟦0⟧ now"},
+		"<p>RUN <code>go build</code> NOW</p><pre>as is</pre><script>if (a<b) x()</script><style>p{}</style>"},
+	6: {"<p>Tom &amp; Jerry&nbsp;&#169;</p><!-- note -->", nil,
+		[]string{"Tom ⟦0⟧ Jerry⟦1⟧⟦2⟧"},
+		"<p>TOM &amp; JERRY&nbsp;&#169;</p><!-- note -->"},
+	7: {"<!DOCTYPE html>\n<html lang=\"en\"><body>Hi</body></html>", &Options{Target: "ja"},
+		[]string{"Hi"},
+		"<!DOCTYPE html>\n<html lang=\"ja\"><body>HI</body></html>"},
+	8: {"<html><body><p lang=\"de\">Hallo</p></body></html>", &Options{Source: "de", Target: "ja"},
+		[]string{"Hallo"},
+		"<html lang=\"ja\"><body><p lang=\"ja\">HALLO</p></body></html>"},
+	9: {`<html lang="en"><p lang="fr">Non</p><p title="a &quot;b&quot;">1 < 2 apples</p></html>`, &Options{Target: "de"},
+		[]string{`a "b"`, "1 < 2 apples"},
+		`<html lang="de"><p lang="fr">Non</p><p title="A &quot;B&quot;">1 &lt; 2 APPLES</p></html>`},
+	10: {"<ul>\n  <li>One</li>\n  <li>  </li>\n</ul>\n", nil,
+		[]string{"One"},
+		"<ul>\n  <li>ONE</li>\n  <li>  </li>\n</ul>\n"},
+	11: {"<p>Hi</p><script>s = '" + strings.Repeat("Ⱥ", 20) + "'</SCRIPT><style>p::before{content:'İ'}</style><p>Bye</p>", nil,
+		[]string{"Hi", "Bye"},
+		"<p>HI</p><script>s = '" + strings.Repeat("Ⱥ", 20) + "'</SCRIPT><style>p::before{content:'İ'}</style><p>BYE</p>"},
+}
+
+func TestParseHTML(t *testing.T) {
+	for i, tt := range htmltests {
+		segs, out := render(t, ParseHTML, tt.in, tt.opts)
+		if fmt.Sprintf("%q", segs) != fmt.Sprintf("%q", tt.segs) {
+			t.Errorf("#%d Segments() = %q, want: %q", i, segs, tt.segs)
+		}
+		if out != tt.out {
+			t.Errorf("#%d Render() = %q, want: %q", i, out, tt.out)
+		}
+	}
+}
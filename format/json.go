@@ -0,0 +1,115 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	register("json", ParseJSON, ".json")
+}
+
+// ParseJSON parses a JSON file, such as the messages of an application
+// for a language. Its string values are translated, while the keys,
+// the other values and the formatting are kept as they are.
+//
+// The values to translate are selected by opts.Include and
+// opts.Exclude, which are the patterns of the keys separated by dots,
+// such as "errors.*.message". The indexes of arrays are keys such as
+// "0". A pattern matches a key as path.Match does, "**" matches any
+// number of keys, and a pattern matching a key also matches its
+// children. The same goes for YAML and TOML.
+func ParseJSON(src []byte, opts *Options) (Document, error) {
+	if !json.Valid(src) {
+		return nil, errors.New("invalid JSON")
+	}
+	sc := &jsonScanner{src: string(src)}
+	sc.value(nil)
+	return treeDoc(sc.src, sc.leaves, opts), nil
+}
+
+type jsonScanner struct {
+	src    string
+	i      int
+	leaves []leaf
+}
+
+func (sc *jsonScanner) skipSpace() {
+	for sc.i < len(sc.src) && strings.IndexByte(" \t\r\n", sc.src[sc.i]) >= 0 {
+		sc.i++
+	}
+}
+
+// value scans a value of the valid JSON at key.
+func (sc *jsonScanner) value(key []string) {
+	sc.skipSpace()
+	if sc.i >= len(sc.src) {
+		return
+	}
+	switch sc.src[sc.i] {
+	case '{':
+		sc.i++
+		for {
+			sc.skipSpace()
+			if sc.src[sc.i] == '}' {
+				sc.i++
+				return
+			}
+			if sc.src[sc.i] == ',' {
+				sc.i++
+				sc.skipSpace()
+			}
+			k := sc.str()
+			sc.skipSpace()
+			sc.i++ // ':'
+			sc.value(appendKey(key, k))
+		}
+	case '[':
+		sc.i++
+		for n := 0; ; n++ {
+			sc.skipSpace()
+			if sc.src[sc.i] == ']' {
+				sc.i++
+				return
+			}
+			if sc.src[sc.i] == ',' {
+				sc.i++
+			}
+			sc.value(appendKey(key, strconv.Itoa(n)))
+		}
+	case '"':
+		start := sc.i
+		s := sc.str()
+		sc.leaves = append(sc.leaves, leaf{key, s, start, sc.i, jsonQuote})
+	default:
+		for sc.i < len(sc.src) && strings.IndexByte(",]} \t\r\n", sc.src[sc.i]) < 0 {
+			sc.i++
+		}
+	}
+}
+
+// str scans a string and returns its value.
+func (sc *jsonScanner) str() string {
+	start := sc.i
+	for sc.i++; sc.i < len(sc.src) && sc.src[sc.i] != '"'; sc.i++ {
+		if sc.src[sc.i] == '\\' {
+			sc.i++
+		}
+	}
+	sc.i++
+	var s string
+	json.Unmarshal([]byte(sc.src[start:sc.i]), &s)
+	return s
+}
+
+// jsonQuote returns s as a JSON string, without escaping HTML.
+func jsonQuote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,217 @@
+package format
+
+import (
+	"regexp"
+	"strings"
+)
+
+func init() {
+	register("md", ParseMarkdown, ".md", ".markdown", ".mkd")
+}
+
+// mdInline matches the inline pieces of Markdown kept untranslated:
+// code spans, link and image destinations, reference labels, autolinks,
+// HTML tags and bare URLs.
+var mdInline = regexp.MustCompile("``[^`]*``|`[^`]*`" +
+	`|\]\([^)]*\)|\]\[[^\]]*\]|<(?:https?://|mailto:)[^>]*>|</?[a-zA-Z][^<>]*>|https?://[^\s)>]+`)
+
+var (
+	mdFence      = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	mdHeading    = regexp.MustCompile(`^( {0,3}#{1,6}[ \t]+)(.*?)([ \t]+#+)?([ \t]*\n?)$`)
+	mdBreak      = regexp.MustCompile(`^ {0,3}(?:(?:-[ \t]*){3,}|(?:\*[ \t]*){3,}|(?:_[ \t]*){3,})\n?$`)
+	mdSetext     = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*\n?$`)
+	mdHTML       = regexp.MustCompile(`^ {0,3}<[a-zA-Z/!?]`)
+	mdLinkDef    = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:`)
+	mdPrefix     = regexp.MustCompile(`^[ \t]*(?:>[ \t]?)*(?:(?:[-*+]|\d{1,9}[.)])[ \t]+(?:\[[ xX]\][ \t]+)?)?`)
+	mdListItem   = regexp.MustCompile(`^[ \t]*(?:[-*+]|\d{1,9}[.)])[ \t]+`)
+	mdQuote      = regexp.MustCompile(`^[ \t]*>`)
+	mdTableDelim = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(\|[ \t]*:?-+:?[ \t]*)*\|?[ \t]*\n?$`)
+	mdCellSep    = regexp.MustCompile(`(^|[^\\])\|`)
+)
+
+// ParseMarkdown parses a Markdown document. The prose of paragraphs,
+// headings, list items, block quotes and table cells is translated,
+// while front matter, code blocks, HTML blocks, link definitions and
+// inline code and URLs are kept as they are. The lines of a paragraph
+// are joined into one line to translate its sentences as a whole.
+func ParseMarkdown(src []byte, opts *Options) (Document, error) {
+	p := &mdParser{lines: splitLines(string(src))}
+	p.parse()
+	return &p.doc, nil
+}
+
+type mdParser struct {
+	doc   doc
+	lines []string
+	i     int
+}
+
+// splitLines splits s after each newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func (p *mdParser) parse() {
+	p.frontMatter()
+	for p.i < len(p.lines) {
+		line := p.lines[p.i]
+		switch {
+		case isBlank(line), mdBreak.MatchString(line), mdSetext.MatchString(line),
+			mdLinkDef.MatchString(line):
+			p.doc.verbatim(line)
+			p.i++
+		case mdFence.MatchString(line):
+			p.fence()
+		case p.isIndentedCode():
+			p.indentedCode()
+		case mdHTML.MatchString(line):
+			p.htmlBlock()
+		case mdHeading.MatchString(line):
+			m := mdHeading.FindStringSubmatch(line)
+			p.doc.verbatim(m[1])
+			p.doc.segment(m[2], mdInline)
+			p.doc.verbatim(m[3] + m[4])
+			p.i++
+		case p.isTable():
+			p.table()
+		default:
+			p.paragraph()
+		}
+	}
+}
+
+// frontMatter keeps YAML (---) or TOML (+++) front matter.
+func (p *mdParser) frontMatter() {
+	if len(p.lines) == 0 {
+		return
+	}
+	delim := strings.TrimRight(p.lines[0], "\r\n")
+	if delim != "---" && delim != "+++" {
+		return
+	}
+	for j := 1; j < len(p.lines); j++ {
+		if strings.TrimRight(p.lines[j], "\r\n") == delim {
+			for ; p.i <= j; p.i++ {
+				p.doc.verbatim(p.lines[p.i])
+			}
+			return
+		}
+	}
+}
+
+func (p *mdParser) fence() {
+	open := mdFence.FindStringSubmatch(p.lines[p.i])[1]
+	p.doc.verbatim(p.lines[p.i])
+	for p.i++; p.i < len(p.lines); p.i++ {
+		line := p.lines[p.i]
+		p.doc.verbatim(line)
+		s := strings.TrimSpace(line)
+		if strings.HasPrefix(s, open) && strings.Trim(s, open[:1]) == "" {
+			p.i++
+			return
+		}
+	}
+}
+
+// isIndentedCode reports whether the line is a code block indented by
+// four spaces after a blank line. Indented list items are not.
+func (p *mdParser) isIndentedCode() bool {
+	line := p.lines[p.i]
+	if !strings.HasPrefix(line, "    ") && !strings.HasPrefix(line, "\t") {
+		return false
+	}
+	if p.i > 0 && !isBlank(p.lines[p.i-1]) {
+		return false
+	}
+	return !mdListItem.MatchString(line)
+}
+
+func (p *mdParser) indentedCode() {
+	for ; p.i < len(p.lines); p.i++ {
+		line := p.lines[p.i]
+		if !isBlank(line) && !strings.HasPrefix(line, "    ") && !strings.HasPrefix(line, "\t") {
+			return
+		}
+		p.doc.verbatim(line)
+	}
+}
+
+func (p *mdParser) htmlBlock() {
+	for ; p.i < len(p.lines) && !isBlank(p.lines[p.i]); p.i++ {
+		p.doc.verbatim(p.lines[p.i])
+	}
+}
+
+func (p *mdParser) isTable() bool {
+	return strings.Contains(p.lines[p.i], "|") && p.i+1 < len(p.lines) &&
+		strings.Contains(p.lines[p.i+1], "-") && mdTableDelim.MatchString(p.lines[p.i+1])
+}
+
+func (p *mdParser) table() {
+	p.row(p.lines[p.i])
+	p.doc.verbatim(p.lines[p.i+1])
+	for p.i += 2; p.i < len(p.lines); p.i++ {
+		line := p.lines[p.i]
+		if isBlank(line) || !strings.Contains(line, "|") {
+			return
+		}
+		p.row(line)
+	}
+}
+
+// row translates each cell of a table row.
+func (p *mdParser) row(line string) {
+	last := 0
+	for _, m := range mdCellSep.FindAllStringIndex(line, -1) {
+		sep := m[1] - 1
+		p.doc.segment(line[last:sep], mdInline)
+		p.doc.verbatim("|")
+		last = m[1]
+	}
+	p.doc.segment(line[last:], mdInline)
+}
+
+// startsBlock reports whether line ends a paragraph by starting
+// another block.
+func startsBlock(line string) bool {
+	return isBlank(line) || mdFence.MatchString(line) || mdHeading.MatchString(line) ||
+		mdBreak.MatchString(line) || mdSetext.MatchString(line) || mdHTML.MatchString(line) ||
+		mdListItem.MatchString(line) || mdQuote.MatchString(line)
+}
+
+// paragraph translates the lines of a paragraph, a list item or a
+// block quote as a line, keeping the prefix of the first line and
+// hard line breaks.
+func (p *mdParser) paragraph() {
+	line := p.lines[p.i]
+	prefix := mdPrefix.FindString(line)
+	p.doc.verbatim(prefix)
+	var texts []string
+	text := line[len(prefix):]
+	for {
+		body := strings.TrimRight(text, "\r\n")
+		eol := text[len(body):]
+		content := strings.TrimRight(body, " \t")
+		if strings.HasSuffix(content, "\\") {
+			content = content[:len(content)-1]
+		}
+		hardBreak := len(body)-len(content) >= 2 || strings.HasSuffix(body, "\\")
+		texts = append(texts, strings.TrimSpace(content))
+		p.i++
+		if hardBreak || p.i >= len(p.lines) || startsBlock(p.lines[p.i]) {
+			p.doc.segment(strings.Join(texts, " "), mdInline)
+			p.doc.verbatim(body[len(content):])
+			p.doc.verbatim(eol)
+			return
+		}
+		text = p.lines[p.i]
+	}
+}
@@ -0,0 +1,46 @@
+package format
+
+import (
+	"fmt"
+	"testing"
+)
+
+type MarkdownTest struct {
+	in   string
+	segs []string
+	out  string
+}
+
+var markdowntests = []MarkdownTest{
+	0: {"", nil, ""},
+	1: {"# Title #\n\nHello world.\n", []string{"Title", "Hello world."}, "# TITLE #\n\nHELLO WORLD.\n"},
+	2: {"Line one\nline two.\n\nNext\n", []string{"Line one line two.", "Next"}, "LINE ONE LINE TWO.\n\nNEXT\n"},
+	3: {"---\ntitle: Hello\n---\nBody\n", []string{"Body"}, "---\ntitle: Hello\n---\nBODY\n"},
+	4: {"Run:\n\n```sh\n$ go run main.go\n```\n\n~~~\ncode\n~~~\n", []string{"Run:"},
+		"RUN:\n\n```sh\n$ go run main.go\n```\n\n~~~\ncode\n~~~\n"},
+	5: {"Use `go build` and [the docs](https://golang.org/doc) now.\n",
+		[]string{"Use ⟦0⟧ and [the docs⟦1⟧ now."},
+		"USE `go build` AND [THE DOCS](https://golang.org/doc) NOW.\n"},
+	6: {"- one\n- two\n  more\n1. [ ] three\n> quote\n", []string{"one", "two more", "three", "quote"},
+		"- ONE\n- TWO MORE\n1. [ ] THREE\n> QUOTE\n"},
+	7: {"| Name | Desc |\n|------|:----:|\n| `a` | first |\n", []string{"Name", "Desc", "first"},
+		"| NAME | DESC |\n|------|:----:|\n| `a` | FIRST |\n"},
+	8: {"Text\n\n    code here\n\n***\n[id]: http://example.com\n", []string{"Text"},
+		"TEXT\n\n    code here\n\n***\n[id]: http://example.com\n"},
+	9: {"<div>\nraw html\n</div>\n\nBreak  \nhere\\\nend\n", []string{"Break", "here", "end"},
+		"<div>\nraw html\n</div>\n\nBREAK  \nHERE\\\nEND\n"},
+	10: {"Title\n=====\n![logo](img.png) ok\n", []string{"Title", "![logo⟦0⟧ ok"}, "TITLE\n=====\n![LOGO](img.png) OK\n"},
+	11: {"No newline", []string{"No newline"}, "NO NEWLINE"},
+}
+
+func TestParseMarkdown(t *testing.T) {
+	for i, tt := range markdowntests {
+		segs, out := render(t, ParseMarkdown, tt.in, nil)
+		if fmt.Sprintf("%q", segs) != fmt.Sprintf("%q", tt.segs) {
+			t.Errorf("#%d Segments() = %q, want: %q", i, segs, tt.segs)
+		}
+		if out != tt.out {
+			t.Errorf("#%d Render() = %q, want: %q", i, out, tt.out)
+		}
+	}
+}
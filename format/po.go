@@ -0,0 +1,239 @@
+package format
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/y-bash/go-tran"
+)
+
+func init() {
+	register("po", ParsePO, ".po", ".pot")
+}
+
+var (
+	poKeyword  = regexp.MustCompile(`^(msgctxt|msgid_plural|msgid|msgstr(?:\[\d+\])?)[ \t]+(".*)$`)
+	poLanguage = regexp.MustCompile(`"Language:[ \t]*(\\n)?"`)
+)
+
+// ParsePO parses a gettext catalog (.po or .pot). The msgstr of each
+// untranslated message is filled with the translation of its msgid,
+// and the forms of a plural message with the translation of its
+// msgid_plural, leaving the message marked fuzzy to be reviewed. The
+// messages already translated are kept unless opts.Force is true. An
+// empty Language of the header is set to the target language.
+func ParsePO(src []byte, opts *Options) (Document, error) {
+	p := &poParser{}
+	if opts != nil {
+		p.opts = *opts
+	}
+	lines := splitLines(string(src))
+	for i := 0; i < len(lines); {
+		if isBlank(lines[i]) {
+			p.doc.verbatim(lines[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(lines) && !isBlank(lines[j]) {
+			j++
+		}
+		p.entry(lines[i:j])
+		i = j
+	}
+	return &p.doc, nil
+}
+
+type poParser struct {
+	doc  doc
+	opts Options
+}
+
+// poField is a keyword of an entry with its string spanning lines.
+type poField struct {
+	keyword     string
+	value       string // unescaped
+	first, last int    // the lines
+}
+
+func (p *poParser) entry(lines []string) {
+	var fields []poField
+	for i, line := range lines {
+		s := strings.TrimSpace(line)
+		if m := poKeyword.FindStringSubmatch(s); m != nil {
+			fields = append(fields, poField{m[1], poUnquote(m[2]), i, i})
+		} else if strings.HasPrefix(s, `"`) && len(fields) > 0 {
+			f := &fields[len(fields)-1]
+			f.value += poUnquote(s)
+			f.last = i
+		}
+	}
+	var ctxt, id, plural *poField
+	var strs []poField
+	for i := range fields {
+		f := &fields[i]
+		switch {
+		case f.keyword == "msgctxt":
+			ctxt = f
+		case f.keyword == "msgid":
+			id = f
+		case f.keyword == "msgid_plural":
+			plural = f
+		default:
+			strs = append(strs, *f)
+		}
+	}
+	if id == nil || len(strs) == 0 {
+		p.verbatim(lines)
+		return
+	}
+	if id.value == "" && ctxt == nil {
+		p.header(lines)
+		return
+	}
+	translated := false
+	for _, f := range strs {
+		translated = translated || f.value != ""
+	}
+	if translated && !p.opts.Force || !hasWords(tran.Placeholder.ReplaceAllString(id.value, "")) {
+		p.verbatim(lines)
+		return
+	}
+
+	eol := lineEnd(lines[0])
+	quote := func(s string) string {
+		return poQuote(s, eol)
+	}
+	fuzzy := false
+	for i, line := range lines {
+		if i == strs[0].first {
+			// The fields of msgstr follow the others.
+			msgstr := p.doc.quoted(id.value, tran.Placeholder, quote)
+			msgstrPlural := msgstr
+			if plural != nil {
+				msgstrPlural = p.doc.quoted(plural.value, tran.Placeholder, quote)
+			}
+			for n, f := range strs {
+				s := msgstr
+				if n > 0 {
+					s = msgstrPlural
+				}
+				p.doc.verbatim(f.keyword + " " + s + eol)
+			}
+			p.doc.verbatim(strings.Join(lines[strs[len(strs)-1].last+1:], ""))
+			return
+		}
+		if !fuzzy && strings.HasPrefix(line, "#,") {
+			if !poHasFlag(line, "fuzzy") {
+				body := strings.TrimRight(line, "\r\n")
+				line = body + ", fuzzy" + line[len(body):]
+			}
+			fuzzy = true
+		}
+		if !fuzzy && (strings.HasPrefix(line, "#|") || !strings.HasPrefix(line, "#")) {
+			p.doc.verbatim("#, fuzzy" + eol)
+			fuzzy = true
+		}
+		p.doc.verbatim(line)
+	}
+}
+
+func (p *poParser) verbatim(lines []string) {
+	for _, line := range lines {
+		p.doc.verbatim(line)
+	}
+}
+
+// header keeps the header entry, setting its empty Language.
+func (p *poParser) header(lines []string) {
+	for _, line := range lines {
+		if p.opts.Target != "" {
+			line = poLanguage.ReplaceAllString(line, `"Language: `+p.opts.Target+`\n"`)
+		}
+		p.doc.verbatim(line)
+	}
+}
+
+func lineEnd(line string) string {
+	if strings.HasSuffix(line, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// poHasFlag reports whether the flags comment line has flag.
+func poHasFlag(line, flag string) bool {
+	for _, f := range strings.Split(strings.TrimSpace(line[2:]), ",") {
+		if strings.TrimSpace(f) == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// poUnquote returns the content of the quoted string s with its escape
+// sequences decoded.
+func poUnquote(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = s[i]; c {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'a':
+			sb.WriteByte('\a')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'v':
+			sb.WriteByte('\v')
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i
+			for j < len(s) && j < i+3 && '0' <= s[j] && s[j] <= '7' {
+				j++
+			}
+			n, _ := strconv.ParseUint(s[i:j], 8, 8)
+			sb.WriteByte(byte(n))
+			i = j - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+var poEscape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+
+// poQuote returns s as a quoted string. A string with line breaks is
+// split into lines ending with eol after them, following an empty
+// string.
+func poQuote(s, eol string) string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) <= 1 {
+		return `"` + poEscape.Replace(s) + `"`
+	}
+	var sb strings.Builder
+	sb.WriteString(`""`)
+	for _, line := range lines {
+		sb.WriteString(eol + `"` + poEscape.Replace(line) + `"`)
+	}
+	return sb.String()
+}
@@ -0,0 +1,94 @@
+package format
+
+import (
+	"fmt"
+	"testing"
+)
+
+type POTest struct {
+	in   string
+	opts *Options
+	segs []string
+	out  string
+}
+
+const poHeader = "msgid \"\"\nmsgstr \"\"\n\"Project-Id-Version: app\\n\"\n\"Language: \\n\"\n\n"
+
+var potests = []POTest{
+	0: {"", nil, nil, ""},
+	1: {poHeader + "#: main.go:10\nmsgid \"Hello, %s!\"\nmsgstr \"\"\n", &Options{Target: "ja"},
+		[]string{"Hello, ⟦0⟧!"},
+		"msgid \"\"\nmsgstr \"\"\n\"Project-Id-Version: app\\n\"\n\"Language: ja\\n\"\n\n" +
+			"#: main.go:10\n#, fuzzy\nmsgid \"Hello, %s!\"\nmsgstr \"HELLO, %s!\"\n"},
+	2: {"#, c-format\nmsgctxt \"menu\"\nmsgid \"Open\"\nmsgstr \"\"\n\nmsgid \"Done\"\nmsgstr \"Fertig\"\n", nil,
+		[]string{"Open"},
+		"#, c-format, fuzzy\nmsgctxt \"menu\"\nmsgid \"Open\"\nmsgstr \"OPEN\"\n\nmsgid \"Done\"\nmsgstr \"Fertig\"\n"},
+	3: {"msgid \"Done\"\nmsgstr \"Fertig\"\n", &Options{Force: true},
+		[]string{"Done"},
+		"#, fuzzy\nmsgid \"Done\"\nmsgstr \"DONE\"\n"},
+	4: {"msgid \"{n} file\"\nmsgid_plural \"{n} files\"\nmsgstr[0] \"\"\nmsgstr[1] \"\"\nmsgstr[2] \"\"\n", nil,
+		[]string{"⟦0⟧ file", "⟦0⟧ files"},
+		"#, fuzzy\nmsgid \"{n} file\"\nmsgid_plural \"{n} files\"\nmsgstr[0] \"{n} FILE\"\nmsgstr[1] \"{n} FILES\"\nmsgstr[2] \"{n} FILES\"\n"},
+	5: {"msgid \"\"\n\"Say \\\"hi\\\"\\n\"\n\"and go.\\n\"\nmsgstr \"\"\n", nil,
+		[]string{"Say \"hi\"\nand go."},
+		"#, fuzzy\nmsgid \"\"\n\"Say \\\"hi\\\"\\n\"\n\"and go.\\n\"\nmsgstr \"\"\n\"SAY \\\"HI\\\"\\n\"\n\"AND GO.\\n\"\n"},
+	6: {"#~ msgid \"Old\"\n#~ msgstr \"\"\n\nmsgid \"%d%%\"\nmsgstr \"\"\n", nil,
+		nil,
+		"#~ msgid \"Old\"\n#~ msgstr \"\"\n\nmsgid \"%d%%\"\nmsgstr \"\"\n"},
+	7: {"msgid \"Done\"\r\nmsgstr \"\"\r\n\r\nmsgid \"\"\r\n\"Say \\\"hi\\\"\\n\"\r\n\"and go.\\n\"\r\nmsgstr \"\"\r\n", nil,
+		[]string{"Done", "Say \"hi\"\nand go."},
+		"#, fuzzy\r\nmsgid \"Done\"\r\nmsgstr \"DONE\"\r\n\r\n" +
+			"#, fuzzy\r\nmsgid \"\"\r\n\"Say \\\"hi\\\"\\n\"\r\n\"and go.\\n\"\r\nmsgstr \"\"\r\n\"SAY \\\"HI\\\"\\n\"\r\n\"AND GO.\\n\"\r\n"},
+}
+
+func TestParsePO(t *testing.T) {
+	for i, tt := range potests {
+		segs, out := render(t, ParsePO, tt.in, tt.opts)
+		if fmt.Sprintf("%q", segs) != fmt.Sprintf("%q", tt.segs) {
+			t.Errorf("#%d Segments() = %q, want: %q", i, segs, tt.segs)
+		}
+		if out != tt.out {
+			t.Errorf("#%d Render() = %q, want: %q", i, out, tt.out)
+		}
+	}
+}
+
+type POUnquoteTest struct {
+	in  string
+	out string
+}
+
+var pounquotetests = []POUnquoteTest{
+	0: {`""`, ""},
+	1: {`"a\"b\\c\n\t"`, "a\"b\\c\n\t"},
+	2: {`"\101\0"`, "A\x00"},
+}
+
+func TestPOUnquote(t *testing.T) {
+	for i, tt := range pounquotetests {
+		if out := poUnquote(tt.in); out != tt.out {
+			t.Errorf("#%d poUnquote(%q) = %q, want: %q", i, tt.in, out, tt.out)
+		}
+	}
+}
+
+type POQuoteTest struct {
+	in  string
+	eol string
+	out string
+}
+
+var poquotetests = []POQuoteTest{
+	0: {"", "\n", `""`},
+	1: {"a\"b\\c\t\n", "\n", `"a\"b\\c\t\n"`},
+	2: {"a\nb", "\n", "\"\"\n\"a\\n\"\n\"b\""},
+	3: {"a\nb", "\r\n", "\"\"\r\n\"a\\n\"\r\n\"b\""},
+}
+
+func TestPOQuote(t *testing.T) {
+	for i, tt := range poquotetests {
+		if out := poQuote(tt.in, tt.eol); out != tt.out {
+			t.Errorf("#%d poQuote(%q, %q) = %q, want: %q", i, tt.in, tt.eol, out, tt.out)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/y-bash/go-tran"
+)
+
+func init() {
+	register("srt", ParseSubtitles, ".srt")
+	register("vtt", ParseSubtitles, ".vtt")
+}
+
+// subTag matches the styling tags of cues kept untranslated: HTML-like
+// tags and WebVTT timestamps, ASS override codes such as {\an8}, and
+// character references.
+var subTag = regexp.MustCompile(`<[^<>]*>|\{\\[^{}]*\}|&#?\w+;`)
+
+// subUnit matches the units which splitText does not split.
+var subUnit = regexp.MustCompile(`(?s)<[^<>]*>|\{\\[^{}]*\}|&#?\w+;|\s+|.`)
+
+// maxMerge is the maximum number of cues merged into a sentence.
+const maxMerge = 4
+
+// ParseSubtitles parses SubRip (.srt) or WebVTT (.vtt) subtitles. The
+// text of each cue is translated as a line, while the cue numbers, the
+// timecodes, the styling tags and the blocks other than cues, such as
+// NOTE and STYLE, are kept as they are. The translations are split into
+// as many lines as the cues had. If opts.MergeCues is true, the cues
+// continuing a sentence are merged to be translated together, and the
+// translation is split into the cues by their lengths.
+func ParseSubtitles(src []byte, opts *Options) (Document, error) {
+	d := &subtitleDoc{merge: opts != nil && opts.MergeCues}
+	lines := splitLines(string(src))
+	for i := 0; i < len(lines); {
+		if isBlank(lines[i]) {
+			d.verbatim(lines[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(lines) && !isBlank(lines[j]) {
+			j++
+		}
+		d.block(lines[i:j])
+		i = j
+	}
+	for _, g := range d.groups {
+		g.text, g.masks = tran.Mask(g.text, subTag)
+	}
+	return d, nil
+}
+
+// cue is the text of a cue, which is rendered into lines of the
+// lengths in proportion to the original lines.
+type cue struct {
+	widths []int // of the original lines
+	eol    string
+	last   string   // the end of the last line
+	out    []string // the rendered lines, without empty ones
+}
+
+// cueGroup is cues translated as a segment.
+type cueGroup struct {
+	text  string
+	masks []string
+	cues  []*cue
+	ended bool // the text ends a sentence
+}
+
+// subItem is a piece of subtitles, which is either a verbatim text or
+// a cue.
+type subItem struct {
+	text string
+	cue  *cue
+}
+
+type subtitleDoc struct {
+	items  []subItem
+	groups []*cueGroup
+	merge  bool
+	open   *cueGroup // to which the next cue may be merged
+}
+
+func (d *subtitleDoc) verbatim(s string) {
+	d.items = append(d.items, subItem{text: s})
+}
+
+// block adds the lines of a block. The lines up to the one with the
+// timecodes are kept, and the rest is the text of the cue.
+func (d *subtitleDoc) block(lines []string) {
+	t := -1
+	for i, line := range lines {
+		if strings.Contains(line, "-->") {
+			t = i
+			break
+		}
+	}
+	if t < 0 || t == len(lines)-1 {
+		for _, line := range lines {
+			d.verbatim(line)
+		}
+		d.open = nil
+		return
+	}
+	for _, line := range lines[:t+1] {
+		d.verbatim(line)
+	}
+	text := lines[t+1:]
+	c := &cue{}
+	var texts []string
+	for _, line := range text {
+		body := strings.TrimRight(line, "\r\n")
+		s := strings.TrimSpace(body)
+		c.widths = append(c.widths, textWidth(s))
+		texts = append(texts, s)
+		if c.eol == "" {
+			c.eol = line[len(body):]
+		}
+		c.last = line[len(body):]
+	}
+	s := strings.Join(texts, " ")
+	if !hasWords(subTag.ReplaceAllString(s, "")) {
+		for _, line := range text {
+			d.verbatim(line)
+		}
+		d.open = nil
+		return
+	}
+	d.items = append(d.items, subItem{cue: c})
+	g := d.open
+	if g == nil || g.ended || len(g.cues) >= maxMerge {
+		g = &cueGroup{}
+		d.groups = append(d.groups, g)
+	} else {
+		g.text += " "
+	}
+	g.text += s
+	g.cues = append(g.cues, c)
+	g.ended = endsSentence(s)
+	if d.merge {
+		d.open = g
+	}
+}
+
+// endsSentence reports whether s ends with a punctuation which ends a
+// sentence, possibly followed by closing quotes or tags.
+func endsSentence(s string) bool {
+	s = strings.TrimRightFunc(subTag.ReplaceAllString(s, ""), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`"')]»”’`, r)
+	})
+	r, _ := utf8.DecodeLastRuneInString(s)
+	return strings.ContainsRune(".!?…。！？♪", r)
+}
+
+func (d *subtitleDoc) Segments() []string {
+	a := make([]string, len(d.groups))
+	for i, g := range d.groups {
+		a[i] = g.text
+	}
+	return a
+}
+
+func (d *subtitleDoc) Unmask(i int, s string) (string, error) {
+	return tran.Unmask(s, d.groups[i].masks)
+}
+
+func (d *subtitleDoc) Render(w io.Writer, translated []string) error {
+	if len(translated) != len(d.groups) {
+		return ErrSegmentCount
+	}
+	for i, g := range d.groups {
+		s, err := tran.Unmask(translated[i], g.masks)
+		if err != nil {
+			return fmt.Errorf("%q: %w", g.text, err)
+		}
+		widths := make([]int, len(g.cues))
+		for j, c := range g.cues {
+			widths[j] = sum(c.widths)
+		}
+		for j, text := range splitText(s, widths) {
+			c := g.cues[j]
+			c.out = nil
+			for _, line := range splitText(text, c.widths) {
+				if line != "" {
+					c.out = append(c.out, line)
+				}
+			}
+		}
+	}
+	var sb strings.Builder
+	for _, it := range d.items {
+		if it.cue == nil {
+			sb.WriteString(it.text)
+			continue
+		}
+		c := it.cue
+		if len(c.out) == 0 {
+			sb.WriteString(c.last)
+		}
+		for j, line := range c.out {
+			sb.WriteString(line)
+			if j < len(c.out)-1 {
+				sb.WriteString(c.eol)
+			} else {
+				sb.WriteString(c.last)
+			}
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func sum(a []int) int {
+	n := 0
+	for _, v := range a {
+		n += v
+	}
+	return n
+}
+
+// textWidth returns the number of the characters of s other than the
+// tags.
+func textWidth(s string) int {
+	return utf8.RuneCountInString(subTag.ReplaceAllString(s, ""))
+}
+
+// splitText splits s into len(widths) lines of the lengths in
+// proportion to widths. It splits s at spaces, or next to the letters
+// of the languages written without spaces if s has no space, but never
+// in words or tags.
+func splitText(s string, widths []int) []string {
+	n := len(widths)
+	if n <= 1 {
+		return []string{s}
+	}
+	total := sum(widths)
+	if total == 0 {
+		widths = make([]int, n)
+		for i := range widths {
+			widths[i] = 1
+		}
+		total = n
+	}
+	units := subUnit.FindAllString(s, -1)
+	spaced := false
+	for _, u := range units {
+		if strings.TrimSpace(u) == "" {
+			spaced = true
+			break
+		}
+	}
+
+	// pos[i] is the length of the text before units[i].
+	pos := make([]int, len(units)+1)
+	for i, u := range units {
+		w := 1
+		if subTag.MatchString(u) && !strings.HasPrefix(u, "&") {
+			w = 0
+		}
+		pos[i+1] = pos[i] + w
+	}
+	length := pos[len(units)]
+
+	// A break before units[b] removes the unit if it is a space.
+	var breaks []int
+	b, acc := 0, 0
+	for k := 0; k < n-1; k++ {
+		acc += widths[k]
+		target := length * acc / total
+		best := -1
+		for c := b + 1; c < len(units); c++ {
+			if spaced && strings.TrimSpace(units[c]) != "" ||
+				!spaced && !isUnspaced(units[c-1]) && !isUnspaced(units[c]) {
+				continue
+			}
+			if best < 0 || abs(pos[c]-target) < abs(pos[best]-target) {
+				best = c
+			}
+			if pos[c] > target {
+				break
+			}
+		}
+		if best < 0 {
+			break
+		}
+		breaks = append(breaks, best)
+		b = best
+	}
+
+	lines := make([]string, 0, n)
+	start := 0
+	for _, b := range breaks {
+		lines = append(lines, strings.TrimSpace(strings.Join(units[start:b], "")))
+		start = b
+	}
+	lines = append(lines, strings.TrimSpace(strings.Join(units[start:], "")))
+	for len(lines) < n {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+// isUnspaced reports whether u is a letter or a punctuation of the
+// languages written without spaces, such as Japanese and Thai.
+func isUnspaced(u string) bool {
+	r, _ := utf8.DecodeRuneInString(u)
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai,
+		unicode.Lao, unicode.Khmer, unicode.Myanmar) ||
+		0x3000 <= r && r <= 0x30ff || 0xff00 <= r && r <= 0xffef
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,66 @@
+package format
+
+import (
+	"fmt"
+	"testing"
+)
+
+type SubtitlesTest struct {
+	in   string
+	opts *Options
+	segs []string
+	out  string
+}
+
+var subtitlestests = []SubtitlesTest{
+	0: {"", nil, nil, ""},
+	1: {"1\n00:00:01,000 --> 00:00:02,500\nHello there,\nmy friend.\n\n2\n00:00:03,000 --> 00:00:04,000\n<i>Bye!</i>\n", nil,
+		[]string{"Hello there, my friend.", "⟦0⟧Bye!⟦1⟧"},
+		"1\n00:00:01,000 --> 00:00:02,500\nHELLO THERE,\nMY FRIEND.\n\n2\n00:00:03,000 --> 00:00:04,000\n<i>BYE!</i>\n"},
+	2: {"WEBVTT\n\nNOTE a comment\n\n00:01.000 --> 00:02.000 align:start\n{\\an8}Look <c.yellow>up</c>\n\n00:03.000 --> 00:04.000\n♪ ♪\n", nil,
+		[]string{"⟦0⟧Look ⟦1⟧up⟦2⟧"},
+		"WEBVTT\n\nNOTE a comment\n\n00:01.000 --> 00:02.000 align:start\n{\\an8}LOOK <c.yellow>UP</c>\n\n00:03.000 --> 00:04.000\n♪ ♪\n"},
+	3: {"1\r\n00:00:01,000 --> 00:00:02,000\r\nI think\r\n\r\n2\r\n00:00:02,000 --> 00:00:03,000\r\nthat we should go.\r\n\r\n3\r\n00:00:04,000 --> 00:00:05,000\r\nYes.\r\n",
+		&Options{MergeCues: true},
+		[]string{"I think that we should go.", "Yes."},
+		"1\r\n00:00:01,000 --> 00:00:02,000\r\nI THINK\r\n\r\n2\r\n00:00:02,000 --> 00:00:03,000\r\nTHAT WE SHOULD GO.\r\n\r\n3\r\n00:00:04,000 --> 00:00:05,000\r\nYES.\r\n"},
+	4: {"1\n00:00:01,000 --> 00:00:02,000\nI think\n\n2\n00:00:02,000 --> 00:00:03,000\nso.", nil,
+		[]string{"I think", "so."},
+		"1\n00:00:01,000 --> 00:00:02,000\nI THINK\n\n2\n00:00:02,000 --> 00:00:03,000\nSO."},
+}
+
+func TestParseSubtitles(t *testing.T) {
+	for i, tt := range subtitlestests {
+		segs, out := render(t, ParseSubtitles, tt.in, tt.opts)
+		if fmt.Sprintf("%q", segs) != fmt.Sprintf("%q", tt.segs) {
+			t.Errorf("#%d Segments() = %q, want: %q", i, segs, tt.segs)
+		}
+		if out != tt.out {
+			t.Errorf("#%d Render() = %q, want: %q", i, out, tt.out)
+		}
+	}
+}
+
+type SplitTextTest struct {
+	in     string
+	widths []int
+	out    []string
+}
+
+var splittexttests = []SplitTextTest{
+	0: {"one two three four", []int{1}, []string{"one two three four"}},
+	1: {"one two three four", []int{7, 10}, []string{"one two", "three four"}},
+	2: {"私はそう思います", []int{1, 1}, []string{"私はそう", "思います"}},
+	3: {`<font color="red">a b</font> c d`, []int{3, 3}, []string{`<font color="red">a b</font>`, "c d"}},
+	4: {"word", []int{2, 2}, []string{"word", ""}},
+	5: {"a b c", []int{0, 0, 0}, []string{"a", "b", "c"}},
+}
+
+func TestSplitText(t *testing.T) {
+	for i, tt := range splittexttests {
+		out := splitText(tt.in, tt.widths)
+		if fmt.Sprintf("%q", out) != fmt.Sprintf("%q", tt.out) {
+			t.Errorf("#%d splitText(%q, %v) = %q, want: %q", i, tt.in, tt.widths, out, tt.out)
+		}
+	}
+}
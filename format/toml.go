@@ -0,0 +1,272 @@
+package format
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+func init() {
+	register("toml", ParseTOML, ".toml")
+}
+
+// ParseTOML parses a TOML file, such as the messages of an application
+// for a language. Its string values are translated, while the keys,
+// the other values, the comments and the formatting are kept as they
+// are. The values are selected by opts as ParseJSON does.
+func ParseTOML(src []byte, opts *Options) (Document, error) {
+	var v map[string]interface{}
+	if _, err := toml.Decode(string(src), &v); err != nil {
+		return nil, err
+	}
+	sc := &tomlScanner{src: string(src), tables: map[string]int{}}
+	sc.scan()
+
+	// The values scanned are the same as the values decoded, unless
+	// the scanner is mistaken, which is not to break the file.
+	var leaves []leaf
+	for _, l := range sc.leaves {
+		if s, ok := tomlLookup(v, l.key).(string); ok && s == l.value {
+			leaves = append(leaves, l)
+		}
+	}
+	return treeDoc(sc.src, leaves, opts), nil
+}
+
+// tomlLookup returns the value of key in the decoded v.
+func tomlLookup(v interface{}, key []string) interface{} {
+	for _, k := range key {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			v = t[k]
+		case []map[string]interface{}:
+			i, err := strconv.Atoi(k)
+			if err != nil || i >= len(t) {
+				return nil
+			}
+			v = t[i]
+		case []interface{}:
+			i, err := strconv.Atoi(k)
+			if err != nil || i >= len(t) {
+				return nil
+			}
+			v = t[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// tomlScanner scans the strings of a valid TOML file.
+type tomlScanner struct {
+	src    string
+	i      int
+	table  []string       // the key of the current table
+	tables map[string]int // the number of each array of tables
+	leaves []leaf
+}
+
+func (sc *tomlScanner) peek() byte {
+	if sc.i < len(sc.src) {
+		return sc.src[sc.i]
+	}
+	return 0
+}
+
+// skipSpace skips spaces, and also newlines and comments if lines is
+// true.
+func (sc *tomlScanner) skipSpace(lines bool) {
+	for sc.i < len(sc.src) {
+		switch c := sc.src[sc.i]; {
+		case c == ' ' || c == '\t':
+			sc.i++
+		case lines && (c == '\r' || c == '\n'):
+			sc.i++
+		case lines && c == '#':
+			for sc.i < len(sc.src) && sc.src[sc.i] != '\n' {
+				sc.i++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (sc *tomlScanner) scan() {
+	for {
+		sc.skipSpace(true)
+		if sc.i >= len(sc.src) {
+			return
+		}
+		if sc.peek() != '[' {
+			sc.keyValue(sc.table)
+			continue
+		}
+		sc.i++
+		array := sc.peek() == '['
+		if array {
+			sc.i++
+		}
+		sc.table = sc.key()
+		if array {
+			name := strings.Join(sc.table, "\x00")
+			sc.table = append(sc.table, strconv.Itoa(sc.tables[name]))
+			sc.tables[name]++
+		}
+		for sc.i < len(sc.src) && sc.src[sc.i] != '\n' && sc.src[sc.i] != '#' {
+			sc.i++
+		}
+	}
+}
+
+// key scans a dotted key.
+func (sc *tomlScanner) key() []string {
+	var key []string
+	for {
+		sc.skipSpace(false)
+		switch sc.peek() {
+		case '"', '\'':
+			start := sc.i
+			key = append(key, sc.str(start))
+		default:
+			start := sc.i
+			for sc.i < len(sc.src) && strings.IndexByte(" \t.=]\r\n", sc.src[sc.i]) < 0 {
+				sc.i++
+			}
+			key = append(key, sc.src[start:sc.i])
+		}
+		sc.skipSpace(false)
+		if sc.peek() != '.' {
+			return key
+		}
+		sc.i++
+	}
+}
+
+// keyValue scans a key/value pair in the table of the key parent.
+func (sc *tomlScanner) keyValue(parent []string) {
+	key := sc.key()
+	sc.skipSpace(false)
+	sc.i++ // '='
+	k := append(append([]string{}, parent...), key...)
+	sc.value(k)
+}
+
+func (sc *tomlScanner) value(key []string) {
+	sc.skipSpace(false)
+	switch sc.peek() {
+	case '"', '\'':
+		start := sc.i
+		s := sc.str(start)
+		raw := sc.src[start:sc.i]
+		quote := tomlBasic
+		switch {
+		case strings.HasPrefix(raw, `"""`):
+			quote = tomlMultiline
+		case strings.HasPrefix(raw, "'''"):
+			quote = tomlMultilineLiteral
+		case raw[0] == '\'':
+			quote = tomlLiteral
+		}
+		sc.leaves = append(sc.leaves, leaf{key, s, start, sc.i, quote})
+	case '[':
+		sc.i++
+		for n := 0; ; n++ {
+			sc.skipSpace(true)
+			if sc.peek() == ']' || sc.i >= len(sc.src) {
+				sc.i++
+				return
+			}
+			sc.value(appendKey(key, strconv.Itoa(n)))
+			sc.skipSpace(true)
+			if sc.peek() == ',' {
+				sc.i++
+			}
+		}
+	case '{':
+		sc.i++
+		for {
+			sc.skipSpace(false)
+			if sc.peek() == '}' || sc.i >= len(sc.src) {
+				sc.i++
+				return
+			}
+			sc.keyValue(key)
+			sc.skipSpace(false)
+			if sc.peek() == ',' {
+				sc.i++
+			}
+		}
+	default:
+		for sc.i < len(sc.src) && strings.IndexByte(",]}#\r\n", sc.src[sc.i]) < 0 {
+			sc.i++
+		}
+	}
+}
+
+// str scans a string starting at start, and returns its value.
+func (sc *tomlScanner) str(start int) string {
+	s := sc.src[start:]
+	var delim string
+	switch {
+	case strings.HasPrefix(s, `"""`), strings.HasPrefix(s, "'''"):
+		delim = s[:3]
+	default:
+		delim = s[:1]
+	}
+	i := len(delim)
+	for i < len(s) && !strings.HasPrefix(s[i:], delim) {
+		if delim[0] == '"' && s[i] == '\\' {
+			i++
+		}
+		i++
+	}
+	i += len(delim)
+	// A multi-line string may end with up to two quotes.
+	for len(delim) == 3 && i < len(s) && s[i] == delim[0] {
+		i++
+	}
+	if i > len(s) {
+		i = len(s)
+	}
+	sc.i = start + i
+	var v struct{ S string }
+	if _, err := toml.Decode("S = "+s[:i], &v); err != nil {
+		return ""
+	}
+	return v.S
+}
+
+var tomlEscape = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`,
+	"\b", `\b`, "\f", `\f`)
+
+func tomlBasic(s string) string {
+	return `"` + tomlEscape.Replace(s) + `"`
+}
+
+func tomlLiteral(s string) string {
+	if strings.ContainsAny(s, "'\n\r") {
+		return tomlBasic(s)
+	}
+	return "'" + s + "'"
+}
+
+// tomlMultiline returns s as a multi-line basic string, whose newline
+// after the opening quotes is trimmed.
+func tomlMultiline(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"""`, `""\"`, "\r", `\r`, "\t", `\t`, "\b", `\b`, "\f", `\f`)
+	s = r.Replace(s)
+	if strings.HasSuffix(s, `"`) {
+		s = s[:len(s)-1] + `\"`
+	}
+	return `"""` + "\n" + s + `"""`
+}
+
+func tomlMultilineLiteral(s string) string {
+	if strings.Contains(s, "'''") || strings.HasSuffix(s, "'") {
+		return tomlMultiline(s)
+	}
+	return "'''\n" + s + "'''"
+}
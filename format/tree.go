@@ -0,0 +1,90 @@
+package format
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/y-bash/go-tran"
+)
+
+// leaf is a string value of a structured file, such as JSON, at the
+// offsets of its raw text in the file.
+type leaf struct {
+	key        []string // the keys and the indexes of its path
+	value      string
+	start, end int
+	quote      func(string) string // returns the raw text of a value
+}
+
+// treeDoc returns the Document of src which translates the values of
+// leaves whose keys are selected by opts.
+func treeDoc(src string, leaves []leaf, opts *Options) Document {
+	sort.Slice(leaves, func(i, j int) bool {
+		return leaves[i].start < leaves[j].start
+	})
+	d := &doc{}
+	last := 0
+	for _, l := range leaves {
+		if l.start < last || !selectKey(l.key, opts) {
+			continue
+		}
+		d.verbatim(src[last:l.start])
+		d.verbatim(d.quoted(l.value, tran.Placeholder, l.quote))
+		last = l.end
+	}
+	d.verbatim(src[last:])
+	return d
+}
+
+// selectKey reports whether the value of key is translated according
+// to the Include and Exclude patterns of opts.
+func selectKey(key []string, opts *Options) bool {
+	if opts == nil {
+		return true
+	}
+	included := len(opts.Include) == 0
+	for _, p := range opts.Include {
+		included = included || matchKey(p, key)
+	}
+	for _, p := range opts.Exclude {
+		if matchKey(p, key) {
+			return false
+		}
+	}
+	return included
+}
+
+// matchKey reports whether pattern matches key or one of its parents.
+// The pattern is the keys separated by dots, each of which is matched
+// as path.Match does, and "**" matches any number of keys.
+func matchKey(pattern string, key []string) bool {
+	return matchKeys(strings.Split(pattern, "."), key)
+}
+
+func matchKeys(pats, key []string) bool {
+	if len(pats) == 0 {
+		return true
+	}
+	if pats[0] == "**" {
+		for i := 0; i <= len(key); i++ {
+			if matchKeys(pats[1:], key[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(key) == 0 {
+		return false
+	}
+	ok, err := path.Match(pats[0], key[0])
+	return ok && err == nil && matchKeys(pats[1:], key[1:])
+}
+
+// appendKey returns a copy of key with k appended, not to share the
+// array of key among leaves.
+func appendKey(key []string, k string) []string {
+	a := make([]string, len(key), len(key)+1)
+	copy(a, key)
+	return append(a, k)
+}
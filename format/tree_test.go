@@ -0,0 +1,135 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type MatchKeyTest struct {
+	pattern string
+	key     string
+	match   bool
+}
+
+var matchkeytests = []MatchKeyTest{
+	0: {"errors", "errors.notFound", true},
+	1: {"errors.*", "errors.notFound", true},
+	2: {"errors.*.message", "errors.notFound.message", true},
+	3: {"errors.*.message", "errors.notFound.code", false},
+	4: {"**.url", "links.0.url", true},
+	5: {"**.url", "url", true},
+	6: {"**.url", "links.0.title", false},
+	7: {"home.t*", "home.title", true},
+	8: {"home", "homepage", false},
+}
+
+func TestMatchKey(t *testing.T) {
+	for i, tt := range matchkeytests {
+		key := strings.Split(tt.key, ".")
+		if match := matchKey(tt.pattern, key); match != tt.match {
+			t.Errorf("#%d matchKey(%q, %q) = %v, want: %v", i, tt.pattern, tt.key, match, tt.match)
+		}
+	}
+}
+
+// TreeTest is a test of the parsers of structured files.
+type TreeTest struct {
+	in   string
+	opts *Options
+	segs []string
+	out  string
+}
+
+func testTree(t *testing.T, name string, parse Parser, tests []TreeTest) {
+	for i, tt := range tests {
+		segs, out := render(t, parse, tt.in, tt.opts)
+		if fmt.Sprintf("%q", segs) != fmt.Sprintf("%q", tt.segs) {
+			t.Errorf("%s #%d Segments() = %q, want: %q", name, i, segs, tt.segs)
+		}
+		if out != tt.out {
+			t.Errorf("%s #%d Render() = %q, want: %q", name, i, out, tt.out)
+		}
+	}
+}
+
+var jsontests = []TreeTest{
+	0: {`{}`, nil, nil, `{}`},
+	1: {"{\n  \"title\": \"Hello, {{name}}!\",\n  \"count\": 3,\n  \"ok\": true,\n  \"items\": [\"one\", \"two\"]\n}\n", nil,
+		[]string{"Hello, ⟦0⟧!", "one", "two"},
+		"{\n  \"title\": \"HELLO, {{name}}!\",\n  \"count\": 3,\n  \"ok\": true,\n  \"items\": [\"ONE\", \"TWO\"]\n}\n"},
+	2: {`{"a":{"x":"say \"hi\"","url":"http://x"},"b":"<b>bold</b> é"}`, &Options{Exclude: []string{"**.url"}},
+		[]string{`say "hi"`, "⟦0⟧bold⟦1⟧ é"},
+		`{"a":{"x":"SAY \"HI\"","url":"http://x"},"b":"<b>BOLD</b> É"}`},
+	3: {`{"a":{"x":"x"},"b":{"y":"y"}}`, &Options{Include: []string{"b"}},
+		[]string{"y"},
+		`{"a":{"x":"x"},"b":{"y":"Y"}}`},
+}
+
+func TestParseJSON(t *testing.T) {
+	testTree(t, "JSON", ParseJSON, jsontests)
+	if _, err := ParseJSON([]byte(`{"a":`), nil); err == nil {
+		t.Errorf("ParseJSON(invalid) have no error")
+	}
+}
+
+var yamltests = []TreeTest{
+	0: {"", nil, nil, ""},
+	1: {"# messages\nen:\n  title: Hello world  # a comment\n  count: 3\n  on: true\n  quoted: \"It's\\tfine\"\n  single: 'Don''t'\n", nil,
+		[]string{"Hello world", "It's\tfine", "Don't"},
+		"# messages\nen:\n  title: HELLO WORLD  # a comment\n  count: 3\n  on: true\n  quoted: \"IT'S\\tFINE\"\n  single: 'DON''T'\n"},
+	2: {"list:\n  - first\n  - [a b, c d]\nlong: this is\n  continued\nbody: |\n  Line one\n  line two\nempty: ''\n", nil,
+		[]string{"first", "a b", "c d", "this is continued", "Line one\nline two"},
+		"list:\n  - FIRST\n  - [A B, C D]\nlong: THIS IS CONTINUED\nbody: |\n  LINE ONE\n  LINE TWO\nempty: ''\n"},
+	3: {"a: null\nb: true words\nc: yes words\n", &Options{Exclude: []string{"c"}},
+		[]string{"true words"},
+		"a: null\nb: TRUE WORDS\nc: yes words\n"},
+}
+
+func TestParseYAML(t *testing.T) {
+	testTree(t, "YAML", ParseYAML, yamltests)
+}
+
+var tomltests = []TreeTest{
+	0: {"", nil, nil, ""},
+	1: {"title = \"Hello\" # comment\ncount = 3\n\n[errors]\nnot_found = 'Not found'\n\"quoted key\" = \"x y\"\n", nil,
+		[]string{"Hello", "Not found", "x y"},
+		"title = \"HELLO\" # comment\ncount = 3\n\n[errors]\nnot_found = 'NOT FOUND'\n\"quoted key\" = \"X Y\"\n"},
+	2: {"[[items]]\nname = \"first\"\n[[items]]\nname = \"second\"\ntags = [\"a b\", \"c\"]\ninline = { text = \"in line\" }\n",
+		&Options{Include: []string{"items.1"}},
+		[]string{"second", "a b", "c", "in line"},
+		"[[items]]\nname = \"first\"\n[[items]]\nname = \"SECOND\"\ntags = [\"A B\", \"C\"]\ninline = { text = \"IN LINE\" }\n"},
+	3: {"body = \"\"\"\nLine one\nline \"two\\\"\"\"\"\n", nil,
+		[]string{"Line one\nline \"two\""},
+		"body = \"\"\"\nLINE ONE\nLINE \"TWO\\\"\"\"\"\n"},
+}
+
+func TestParseTOML(t *testing.T) {
+	testTree(t, "TOML", ParseTOML, tomltests)
+	if _, err := ParseTOML([]byte("a = "), nil); err == nil {
+		t.Errorf("ParseTOML(invalid) have no error")
+	}
+}
+
+type YAMLPlainTest struct {
+	in  string
+	out string
+}
+
+var yamlplaintests = []YAMLPlainTest{
+	0: {"Hello world", "Hello world"},
+	1: {"Note: read this", `"Note: read this"`},
+	2: {"true", `"true"`},
+	3: {"123", `"123"`},
+	4: {"- item", `"- item"`},
+	5: {"a, b", `"a, b"`},
+	6: {"", `""`},
+}
+
+func TestYAMLPlain(t *testing.T) {
+	for i, tt := range yamlplaintests {
+		if out := yamlPlain(tt.in); out != tt.out {
+			t.Errorf("#%d yamlPlain(%q) = %q, want: %q", i, tt.in, out, tt.out)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/y-bash/go-tran"
+)
+
+func init() {
+	register("xliff", ParseXLIFF, ".xlf", ".xliff")
+}
+
+// xliffCode is the inline elements of XLIFF 1.2 holding native codes,
+// which are masked with their contents. The other inline elements,
+// such as g, x, pc and ph of XLIFF 2.0, are masked tag by tag.
+var xliffCode = setOf("ph bpt ept it")
+
+// ParseXLIFF parses an XLIFF 1.2 or 2.0 file. The target of each
+// untranslated unit, which has no target or an empty one, is filled
+// with the translation of its source, and the unit is marked to be
+// reviewed: state="needs-review-translation" of the target in XLIFF
+// 1.2, and state="translated" of the segment in XLIFF 2.0. The inline
+// markup of the source is kept in the target. The units translated are
+// kept unless opts.Force is true, and the units with translate="no"
+// are kept. The target language of the file is set if it is missing,
+// and the languages declared are reported by the LanguageDocument.
+func ParseXLIFF(src []byte, opts *Options) (Document, error) {
+	p := &xliffParser{sc: htmlScanner{src: string(src)}}
+	if opts != nil {
+		p.opts = *opts
+	}
+	p.parse()
+	return &p.doc, nil
+}
+
+type xliffParser struct {
+	doc  xliffDoc
+	sc   htmlScanner
+	opts Options
+	v2   bool
+	skip int // the depth of the elements with translate="no"
+}
+
+func (p *xliffParser) parse() {
+	for {
+		t, ok := p.sc.next()
+		if !ok {
+			return
+		}
+		if t.kind == htmlEndTag && p.skip > 0 {
+			p.skip--
+		}
+		if t.kind != htmlStartTag {
+			p.doc.verbatim(t.raw)
+			continue
+		}
+		if v, _ := t.attr("translate"); !t.selfClosing && (p.skip > 0 || strings.EqualFold(v, "no")) {
+			p.skip++
+		}
+		switch {
+		case t.name == "xliff":
+			v, _ := t.attr("version")
+			p.v2 = strings.HasPrefix(v, "2")
+			if !p.v2 {
+				p.doc.verbatim(t.raw)
+				break
+			}
+			p.doc.verbatim(p.language(t, "srcLang", "trgLang"))
+		case t.name == "file" && !p.v2:
+			p.doc.verbatim(p.language(t, "source-language", "target-language"))
+		case p.skip == 0 && !t.selfClosing &&
+			(t.name == "trans-unit" && !p.v2 || t.name == "segment" && p.v2):
+			p.unit(append([]htmlToken{t}, p.tokens(t.name)...))
+		default:
+			p.doc.verbatim(t.raw)
+		}
+	}
+}
+
+// xliffDoc is an XLIFF document with the languages of the first file.
+type xliffDoc struct {
+	doc
+	source, target string
+}
+
+func (d *xliffDoc) Languages() (source, target string) {
+	return d.source, d.target
+}
+
+// language records the languages of the attributes source and target
+// of the tag t, and returns t with the attribute target set if it is
+// missing.
+func (p *xliffParser) language(t htmlToken, source, target string) string {
+	if v, ok := t.attr(strings.ToLower(source)); ok && p.doc.source == "" {
+		p.doc.source = v
+	}
+	v, ok := t.attr(strings.ToLower(target))
+	if ok && p.doc.target == "" {
+		p.doc.target = v
+	}
+	if ok || p.opts.Target == "" {
+		return t.raw
+	}
+	return t.withAttr(target, p.opts.Target)
+}
+
+// tokens returns the tokens up to and including the end tag of the
+// element name, whose start tag has been scanned.
+func (p *xliffParser) tokens(name string) []htmlToken {
+	var a []htmlToken
+	depth := 1
+	for depth > 0 {
+		t, ok := p.sc.next()
+		if !ok {
+			break
+		}
+		switch {
+		case t.kind == htmlStartTag && t.name == name && !t.selfClosing:
+			depth++
+		case t.kind == htmlEndTag && t.name == name:
+			depth--
+		}
+		a = append(a, t)
+	}
+	return a
+}
+
+// element returns the indexes of the start and the end tag of the
+// first element name in ts, or -1 if it is missing.
+func element(ts []htmlToken, name string) (start, end int) {
+	start, end = -1, -1
+	for i, t := range ts {
+		switch {
+		case start < 0 && t.kind == htmlStartTag && t.name == name:
+			start = i
+			if t.selfClosing {
+				return start, start
+			}
+		case start >= 0 && t.kind == htmlEndTag && t.name == name:
+			return start, i
+		}
+	}
+	return -1, -1
+}
+
+// unit translates the source of a trans-unit of XLIFF 1.2 or a segment
+// of XLIFF 2.0 made of ts.
+func (p *xliffParser) unit(ts []htmlToken) {
+	verbatim := func(ts []htmlToken) {
+		for _, t := range ts {
+			p.doc.verbatim(t.raw)
+		}
+	}
+	ss, se := element(ts, "source")
+	ts0, te := element(ts, "target")
+	if ss < 0 || se <= ss || ts0 >= 0 && ts0 < se {
+		verbatim(ts)
+		return
+	}
+	masked, masks := xliffMask(ts[ss+1 : se])
+	if !hasWords(tran.StripMasks(masked)) || p.translated(ts, ts0, te) && !p.opts.Force {
+		verbatim(ts)
+		return
+	}
+
+	unit := ts[0].raw
+	if p.v2 {
+		unit = ts[0].withAttr("state", "translated")
+	}
+	p.doc.verbatim(unit)
+	if ts0 >= 0 {
+		verbatim(ts[1:ts0])
+		target := ts[ts0].raw
+		if !p.v2 {
+			target = ts[ts0].withAttr("state", "needs-review-translation")
+		}
+		if ts0 < te {
+			p.doc.verbatim(target)
+			p.doc.verbatim(p.doc.maskedSegment(masked, masks, escapeText))
+			verbatim(ts[te:])
+			return
+		}
+		// <target/> is opened and closed.
+		target = strings.TrimSuffix(strings.TrimSuffix(target, ">"), "/")
+		p.doc.verbatim(strings.TrimRight(target, " \t\r\n") + ">")
+		p.doc.verbatim(p.doc.maskedSegment(masked, masks, escapeText))
+		p.doc.verbatim("</" + ts[ts0].raw[1:1+len(ts[ts0].name)] + ">")
+		verbatim(ts[te+1:])
+		return
+	}
+
+	// The target is added after the source, indented as the source.
+	verbatim(ts[1 : se+1])
+	indent := ""
+	if ss > 0 && ts[ss-1].kind == htmlText && strings.TrimSpace(ts[ss-1].raw) == "" {
+		indent = ts[ss-1].raw
+	}
+	name := ts[se].raw[2 : len(ts[se].raw)-1] // of </source>, with its prefix
+	name = strings.TrimSuffix(strings.TrimSpace(name), "source") + "target"
+	open := "<" + name + ">"
+	if !p.v2 {
+		open = "<" + name + ` state="needs-review-translation">`
+	}
+	p.doc.verbatim(indent + open)
+	p.doc.verbatim(p.doc.maskedSegment(masked, masks, escapeText))
+	p.doc.verbatim("</" + name + ">")
+	verbatim(ts[se+1:])
+}
+
+// translated reports whether the unit ts has a target translated, which
+// is at ts[start:end+1].
+func (p *xliffParser) translated(ts []htmlToken, start, end int) bool {
+	if start < 0 || start == end {
+		return false
+	}
+	var sb strings.Builder
+	for _, t := range ts[start+1 : end] {
+		sb.WriteString(t.raw)
+	}
+	if strings.TrimSpace(sb.String()) == "" {
+		return false
+	}
+	if p.v2 {
+		state, _ := ts[0].attr("state")
+		return state != "initial"
+	}
+	state, _ := ts[start].attr("state")
+	return state != "new" && state != "needs-translation"
+}
+
+// xliffMask returns the text of the contents of an element ts, whose
+// inline markup and character references are masked.
+func xliffMask(ts []htmlToken) (masked string, masks []string) {
+	var sb strings.Builder
+	add := func(s string) {
+		masks = append(masks, s)
+		sb.WriteString(tran.MaskToken(len(masks) - 1))
+	}
+	for i := 0; i < len(ts); i++ {
+		t := ts[i]
+		switch {
+		case t.kind == htmlText:
+			sb.WriteString(htmlEntity.ReplaceAllStringFunc(t.raw, func(m string) string {
+				masks = append(masks, m)
+				return tran.MaskToken(len(masks) - 1)
+			}))
+		case t.kind == htmlStartTag && xliffCode[t.name] && !t.selfClosing:
+			raw := t.raw
+			for depth := 1; depth > 0 && i+1 < len(ts); {
+				i++
+				switch u := ts[i]; {
+				case u.kind == htmlStartTag && u.name == t.name && !u.selfClosing:
+					depth++
+				case u.kind == htmlEndTag && u.name == t.name:
+					depth--
+				}
+				raw += ts[i].raw
+			}
+			add(raw)
+		default:
+			add(t.raw)
+		}
+	}
+	return sb.String(), masks
+}
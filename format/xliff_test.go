@@ -0,0 +1,88 @@
+package format
+
+import (
+	"fmt"
+	"testing"
+)
+
+type XLIFFTest struct {
+	in   string
+	opts *Options
+	segs []string
+	out  string
+}
+
+var xlifftests = []XLIFFTest{
+	0: {"", nil, nil, ""},
+	1: {`<?xml version="1.0"?>
+<xliff version="1.2"><file source-language="en" datatype="plaintext"><body>
+  <trans-unit id="1">
+    <source>Hello <g id="1">big</g> world<x id="2"/> &amp; more</source>
+  </trans-unit>
+  <trans-unit id="2"><source>Done</source><target state="translated">Fertig</target></trans-unit>
+  <trans-unit id="3" translate="no"><source>Keep</source></trans-unit>
+  <trans-unit id="4"><source>Empty</source><target state="new"/></trans-unit>
+</body></file></xliff>`, &Options{Target: "de"},
+		[]string{"Hello ⟦0⟧big⟦1⟧ world⟦2⟧ ⟦3⟧ more", "Empty"},
+		`<?xml version="1.0"?>
+<xliff version="1.2"><file target-language="de" source-language="en" datatype="plaintext"><body>
+  <trans-unit id="1">
+    <source>Hello <g id="1">big</g> world<x id="2"/> &amp; more</source>
+    <target state="needs-review-translation">HELLO <g id="1">BIG</g> WORLD<x id="2"/> &amp; MORE</target>
+  </trans-unit>
+  <trans-unit id="2"><source>Done</source><target state="translated">Fertig</target></trans-unit>
+  <trans-unit id="3" translate="no"><source>Keep</source></trans-unit>
+  <trans-unit id="4"><source>Empty</source><target state="needs-review-translation">EMPTY</target></trans-unit>
+</body></file></xliff>`},
+	2: {`<trans-unit id="1"><source>Use <ph id="1">&lt;b&gt;</ph>x<ph id="2">&lt;/b&gt;</ph> & go</source><target></target></trans-unit>`, nil,
+		[]string{"Use ⟦0⟧x⟦1⟧ & go"},
+		`<trans-unit id="1"><source>Use <ph id="1">&lt;b&gt;</ph>x<ph id="2">&lt;/b&gt;</ph> & go</source><target state="needs-review-translation">USE <ph id="1">&lt;b&gt;</ph>X<ph id="2">&lt;/b&gt;</ph> &amp; GO</target></trans-unit>`},
+	3: {`<xliff version="2.0" srcLang="en"><file id="f"><unit id="1"><segment><source>Hi <pc id="1">there</pc><ph id="2"/></source></segment></unit>` +
+		`<unit id="2"><segment state="final"><source>Bye</source><target>Tschüss</target></segment></unit>` +
+		`<unit id="3" translate="no"><segment><source>No</source></segment></unit></file></xliff>`, &Options{Target: "de"},
+		[]string{"Hi ⟦0⟧there⟦1⟧⟦2⟧"},
+		`<xliff trgLang="de" version="2.0" srcLang="en"><file id="f"><unit id="1"><segment state="translated"><source>Hi <pc id="1">there</pc><ph id="2"/></source><target>HI <pc id="1">THERE</pc><ph id="2"/></target></segment></unit>` +
+			`<unit id="2"><segment state="final"><source>Bye</source><target>Tschüss</target></segment></unit>` +
+			`<unit id="3" translate="no"><segment><source>No</source></segment></unit></file></xliff>`},
+	4: {`<xliff version="2.0"><unit id="2"><segment state="final"><source>Bye</source><target>Tschüss</target></segment></unit></xliff>`,
+		&Options{Force: true},
+		[]string{"Bye"},
+		`<xliff version="2.0"><unit id="2"><segment state="translated"><source>Bye</source><target>BYE</target></segment></unit></xliff>`},
+}
+
+func TestParseXLIFF(t *testing.T) {
+	for i, tt := range xlifftests {
+		segs, out := render(t, ParseXLIFF, tt.in, tt.opts)
+		if fmt.Sprintf("%q", segs) != fmt.Sprintf("%q", tt.segs) {
+			t.Errorf("#%d Segments() = %q, want: %q", i, segs, tt.segs)
+		}
+		if out != tt.out {
+			t.Errorf("#%d Render() = %q, want: %q", i, out, tt.out)
+		}
+	}
+}
+
+type XLIFFLanguagesTest struct {
+	in             string
+	source, target string
+}
+
+var xlifflanguagestests = []XLIFFLanguagesTest{
+	0: {`<xliff version="1.2"><file datatype="plaintext"></file></xliff>`, "", ""},
+	1: {`<xliff version="1.2"><file source-language="en-US" target-language="ja-JP"></file>` +
+		`<file source-language="fr" target-language="de"></file></xliff>`, "en-US", "ja-JP"},
+	2: {`<xliff version="2.0" srcLang="en" trgLang="ja"><file id="f"></file></xliff>`, "en", "ja"},
+}
+
+func TestParseXLIFF_Languages(t *testing.T) {
+	for i, tt := range xlifflanguagestests {
+		d, err := ParseXLIFF([]byte(tt.in), &Options{Target: "en"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		source, target := d.(LanguageDocument).Languages()
+		if source != tt.source || target != tt.target {
+			t.Errorf("#%d Languages() = (%q, %q), want: (%q, %q)", i, source, target, tt.source, tt.target)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	register("yaml", ParseYAML, ".yaml", ".yml")
+}
+
+// ParseYAML parses a YAML file, such as the messages of an application
+// for a language. Its string values are translated, while the keys,
+// the other values, the comments and the formatting are kept as they
+// are. The values are selected by opts as ParseJSON does.
+func ParseYAML(src []byte, opts *Options) (Document, error) {
+	sc := &yamlScanner{src: string(src)}
+	for i := 0; i < len(sc.src); i++ {
+		if i == 0 || sc.src[i-1] == '\n' {
+			sc.lines = append(sc.lines, i)
+		}
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(src))
+	for {
+		var n yaml.Node
+		err := dec.Decode(&n)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		sc.node(&n, nil)
+	}
+	return treeDoc(sc.src, sc.leaves, opts), nil
+}
+
+type yamlScanner struct {
+	src    string
+	lines  []int // the offsets of the lines
+	leaves []leaf
+}
+
+func (sc *yamlScanner) node(n *yaml.Node, key []string) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			sc.node(c, key)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			sc.node(n.Content[i+1], appendKey(key, n.Content[i].Value))
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			sc.node(c, appendKey(key, strconv.Itoa(i)))
+		}
+	case yaml.ScalarNode:
+		if n.ShortTag() != "!!str" || n.Style&yaml.TaggedStyle != 0 || n.Anchor != "" {
+			return
+		}
+		if l, ok := sc.scalar(n); ok {
+			l.key = key
+			sc.leaves = append(sc.leaves, l)
+		}
+	}
+}
+
+// offset returns the offset of the line and the column, counted in
+// characters from 1.
+func (sc *yamlScanner) offset(line, column int) int {
+	if line < 1 || line > len(sc.lines) {
+		return -1
+	}
+	i := sc.lines[line-1]
+	for c := 1; c < column && i < len(sc.src) && sc.src[i] != '\n'; c++ {
+		_, size := utf8.DecodeRuneInString(sc.src[i:])
+		i += size
+	}
+	return i
+}
+
+// scalar returns the leaf of the raw text of the scalar n.
+func (sc *yamlScanner) scalar(n *yaml.Node) (l leaf, ok bool) {
+	start := sc.offset(n.Line, n.Column)
+	if start < 0 || start >= len(sc.src) {
+		return l, false
+	}
+	l = leaf{value: n.Value, start: start}
+	s := sc.src[start:]
+	switch {
+	case n.Style&yaml.DoubleQuotedStyle != 0 && s[0] == '"':
+		i := 1
+		for i < len(s) && s[i] != '"' {
+			if s[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		l.end, l.quote = start+i+1, strconv.Quote
+	case n.Style&yaml.SingleQuotedStyle != 0 && s[0] == '\'':
+		i := 1
+		for i < len(s) && (s[i] != '\'' || strings.HasPrefix(s[i:], "''")) {
+			if s[i] == '\'' {
+				i++
+			}
+			i++
+		}
+		l.end, l.quote = start+i+1, yamlSingleQuote
+	case n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		return sc.block(l)
+	default:
+		return sc.plain(l)
+	}
+	return l, l.end <= len(sc.src)
+}
+
+// block returns the leaf of the content lines of a literal or folded
+// block scalar, whose indicator is at l.start.
+func (sc *yamlScanner) block(l leaf) (leaf, bool) {
+	nl := strings.IndexByte(sc.src[l.start:], '\n')
+	if nl < 0 {
+		return l, false
+	}
+	l.start += nl + 1
+	l.end = l.start
+	indent := ""
+	for i := l.start; i < len(sc.src); {
+		end := strings.IndexByte(sc.src[i:], '\n')
+		if end < 0 {
+			end = len(sc.src)
+		} else {
+			end += i
+		}
+		line := strings.TrimRight(sc.src[i:end], "\r")
+		if strings.TrimSpace(line) != "" {
+			n := len(line) - len(strings.TrimLeft(line, " "))
+			if indent == "" {
+				indent = line[:n]
+			}
+			if indent == "" || n < len(indent) {
+				break
+			}
+			l.end = i + len(line)
+		}
+		i = end + 1
+	}
+	if l.end == l.start {
+		return l, false
+	}
+	l.quote = func(s string) string {
+		lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = indent + line
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+	return l, true
+}
+
+// plain returns the leaf of a plain scalar, which may continue over
+// lines folded into spaces.
+func (sc *yamlScanner) plain(l leaf) (leaf, bool) {
+	l.quote = yamlPlain
+	if strings.HasPrefix(sc.src[l.start:], l.value) {
+		l.end = l.start + len(l.value)
+		return l, true
+	}
+	v, breaks := "", 0
+	for i := l.start; i < len(sc.src) && len(v) < len(l.value); {
+		end := strings.IndexByte(sc.src[i:], '\n')
+		if end < 0 {
+			end = len(sc.src)
+		} else {
+			end += i
+		}
+		line := strings.TrimSpace(sc.src[i:end])
+		switch {
+		case line == "":
+			breaks++
+		case v == "":
+			v = line
+		case breaks == 0:
+			v += " " + line
+		default:
+			v += strings.Repeat("\n", breaks) + line
+			breaks = 0
+		}
+		if line != "" && v == l.value {
+			l.end = i + strings.Index(sc.src[i:end], line) + len(line)
+			return l, true
+		}
+		i = end + 1
+	}
+	return l, false
+}
+
+// yamlPlain returns s as a plain scalar if it is read as the same
+// string, or else as a double-quoted one.
+func yamlPlain(s string) string {
+	if s == "" || s != strings.TrimSpace(s) || strings.ContainsAny(s, "\n,[]{}#") ||
+		strings.ContainsAny(s[:1], "-?:!&*|>'\"%@`") || strings.Contains(s, ": ") ||
+		strings.HasSuffix(s, ":") {
+		return strconv.Quote(s)
+	}
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil || v != s {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func yamlSingleQuote(s string) string {
+	if strings.ContainsAny(s, "\n\r") {
+		return strconv.Quote(s)
+	}
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
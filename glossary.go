@@ -0,0 +1,303 @@
+package tran
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Term is an entry of a Glossary, which forces the translation of
+// Source to be Target. A term whose Target is empty is not translated.
+type Term struct {
+	Source string
+	Target string
+}
+
+// Glossary is the terms of a language pair. The terms are matched
+// ignoring case, as whole words if they begin or end with a letter or a
+// digit. They are not to be changed after the first Check, which
+// compiles them.
+type Glossary struct {
+	// Source and Target are the language codes of the pair. An empty
+	// Source matches any source language.
+	Source string
+	Target string
+	Terms  []Term
+
+	once  sync.Once
+	terms *terms // of Check
+}
+
+// LoadGlossary loads the Glossary of the language pair from the CSV or
+// the TOML file of path, which is read as ReadGlossary does.
+func LoadGlossary(path, source, target string) (*Glossary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	terms, err := ReadGlossary(f, strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Glossary{Source: source, Target: target, Terms: terms}, nil
+}
+
+// ReadGlossary reads the terms of kind "csv" or "toml" from r.
+//
+// A CSV file has a term and its translation in each record, and the
+// translation is missing or empty for a term not to translate. The
+// lines beginning with "#" and the header "term,translation" are
+// skipped.
+//
+// A TOML file has the translations of the terms in the table "terms",
+// and the terms not to translate in the array "keep":
+//
+//	keep = ["GO-TRAN"]
+//
+//	[terms]
+//	"Apps Script" = "Apps Script"
+//	widget = "ウィジェット"
+func ReadGlossary(r io.Reader, kind string) ([]Term, error) {
+	var terms []Term
+	switch strings.ToLower(kind) {
+	case "csv":
+		cr := csv.NewReader(r)
+		cr.Comment = '#'
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+		for i := 0; ; i++ {
+			rec, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			t := Term{Source: strings.TrimSpace(rec[0])}
+			if len(rec) > 1 {
+				t.Target = strings.TrimSpace(rec[1])
+			}
+			if i == 0 && strings.EqualFold(t.Source, "term") && strings.EqualFold(t.Target, "translation") {
+				continue
+			}
+			if t.Source != "" {
+				terms = append(terms, t)
+			}
+		}
+	case "toml":
+		var v struct {
+			Keep  []string          `toml:"keep"`
+			Terms map[string]string `toml:"terms"`
+		}
+		if _, err := toml.DecodeReader(r, &v); err != nil {
+			return nil, err
+		}
+		for _, s := range v.Keep {
+			terms = append(terms, Term{Source: s})
+		}
+		var a []string
+		for s := range v.Terms {
+			a = append(a, s)
+		}
+		sort.Strings(a)
+		for _, s := range a {
+			terms = append(terms, Term{Source: s, Target: v.Terms[s]})
+		}
+	default:
+		return nil, fmt.Errorf("unknown glossary format: %q, want: csv or toml", kind)
+	}
+	return terms, nil
+}
+
+// Violation is a term of a Glossary not translated as it is forced to
+// be.
+type Violation struct {
+	Term Term
+	Want string // the translation wanted, or the term not to translate
+}
+
+func (v Violation) String() string {
+	if v.Term.Target == "" {
+		return fmt.Sprintf("%q is translated, want: kept as it is", v.Want)
+	}
+	return fmt.Sprintf("%q is not translated as %q", v.Term.Source, v.Want)
+}
+
+// terms are the terms of glossaries for a language pair, and the
+// pattern matching them and the placeholders.
+type terms struct {
+	pattern *regexp.Regexp
+	byKey   map[string]Term
+}
+
+// termsFor returns the terms of gs for the language pair, of which the
+// source may be empty if it is unknown. The first glossary has priority
+// over the others for the same term.
+func termsFor(gs []*Glossary, source, target string) *terms {
+	ts := &terms{byKey: map[string]Term{}}
+	var a []string
+	for _, g := range gs {
+		if g.Target != target || g.Source != "" && source != "" && g.Source != source {
+			continue
+		}
+		for _, t := range g.Terms {
+			key := strings.ToLower(t.Source)
+			if _, ok := ts.byKey[key]; ok || t.Source == "" {
+				continue
+			}
+			ts.byKey[key] = t
+			a = append(a, t.Source)
+		}
+	}
+	if len(a) == 0 {
+		return ts
+	}
+	// The longer terms are matched before the shorter ones in them.
+	sort.SliceStable(a, func(i, j int) bool { return len(a[i]) > len(a[j]) })
+	for i, s := range a {
+		a[i] = regexp.QuoteMeta(s)
+		if r, _ := utf8.DecodeRuneInString(s); isWordRune(r) {
+			a[i] = `\b` + a[i]
+		}
+		if r, _ := utf8.DecodeLastRuneInString(s); isWordRune(r) {
+			a[i] += `\b`
+		}
+	}
+	// The placeholders are matched to keep the terms in them.
+	re := regexp.MustCompile("(?i)" + strings.Join(a, "|"))
+	ts.pattern = alternate(append(append([]*regexp.Regexp{}, DefaultPlaceholders...), re))
+	return ts
+}
+
+// isWordRune reports whether r is a character of a word for \b.
+func isWordRune(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// each calls f with the terms in s and the texts matching them.
+func (ts *terms) each(s string, f func(t Term, match string)) {
+	if ts.pattern == nil {
+		return
+	}
+	for _, m := range ts.pattern.FindAllString(s, -1) {
+		if t, ok := ts.byKey[strings.ToLower(m)]; ok {
+			f(t, m)
+		}
+	}
+}
+
+// Check returns the terms of g in source which are not translated as
+// they are forced to be in translation.
+func (g *Glossary) Check(source, translation string) []Violation {
+	var vs []Violation
+	seen := map[string]bool{}
+	lower := strings.ToLower(translation)
+	g.once.Do(func() {
+		g.terms = termsFor([]*Glossary{g}, g.Source, g.Target)
+	})
+	g.terms.each(source, func(t Term, m string) {
+		want := t.Target
+		if want == "" {
+			want = m
+		}
+		if seen[t.Source] || strings.Contains(lower, strings.ToLower(want)) {
+			return
+		}
+		seen[t.Source] = true
+		vs = append(vs, Violation{Term: t, Want: want})
+	})
+	return vs
+}
+
+// GlossaryTranslator is a Translator which forces the translations of
+// the terms of Glossaries. The terms are replaced with masks such as
+// "⟦0⟧" before the translation, and with their translations after it,
+// or restored as they are if they are not to translate.
+type GlossaryTranslator struct {
+	Translator
+	Glossaries []*Glossary
+
+	mu    sync.Mutex
+	terms map[[2]string]*terms
+}
+
+// NewGlossaryTranslator returns a GlossaryTranslator which forces the
+// terms of gs, in order of priority.
+func NewGlossaryTranslator(tr Translator, gs ...*Glossary) *GlossaryTranslator {
+	return &GlossaryTranslator{Translator: tr, Glossaries: gs}
+}
+
+func (g *GlossaryTranslator) Translate(text, source, target string) (string, error) {
+	return g.TranslateContext(context.Background(), text, source, target)
+}
+
+func (g *GlossaryTranslator) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	res, err := g.TranslateResult(ctx, text, source, target)
+	if err != nil {
+		return "", err
+	}
+	return res.Text, nil
+}
+
+func (g *GlossaryTranslator) TranslateResult(ctx context.Context, text, source, target string) (*Result, error) {
+	masked, masks := g.mask(text, source, target)
+	res, err := TranslateResult(ctx, g.Translator, masked, source, target)
+	if err != nil || len(masks) == 0 {
+		return res, err
+	}
+	s, err := Unmask(res.Text, masks)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Text: s, Source: res.Source, Cached: res.Cached, Matches: res.Matches, Pseudo: res.Pseudo}, nil
+}
+
+// mask replaces the terms of s with masks, and returns their
+// translations. The masks already in s are also masked to be kept.
+func (g *GlossaryTranslator) mask(s, source, target string) (masked string, masks []string) {
+	g.mu.Lock()
+	if g.terms == nil {
+		g.terms = map[[2]string]*terms{}
+	}
+	ts, ok := g.terms[[2]string{source, target}]
+	if !ok {
+		ts = termsFor(g.Glossaries, source, target)
+		g.terms[[2]string{source, target}] = ts
+	}
+	g.mu.Unlock()
+
+	if ts.pattern == nil {
+		return s, nil
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range ts.pattern.FindAllStringIndex(s, -1) {
+		match := s[m[0]:m[1]]
+		t, ok := ts.byKey[strings.ToLower(match)]
+		switch {
+		case ok && t.Target != "":
+		case ok || maskPattern.FindString(match) == match:
+			t.Target = match
+		default:
+			continue
+		}
+		sb.WriteString(s[last:m[0]])
+		masks = append(masks, t.Target)
+		sb.WriteString(MaskToken(len(masks) - 1))
+		last = m[1]
+	}
+	sb.WriteString(s[last:])
+	return sb.String(), masks
+}
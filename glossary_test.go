@@ -0,0 +1,120 @@
+package tran
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type ReadGlossaryTest struct {
+	in    string
+	kind  string
+	terms []Term
+	err   bool
+}
+
+var readglossarytests = []ReadGlossaryTest{
+	0: {"term,translation\n# comment\nwidget, ウィジェット\nGO-TRAN\n\"Apps Script\",\n", "csv",
+		[]Term{{"widget", "ウィジェット"}, {"GO-TRAN", ""}, {"Apps Script", ""}}, false},
+	1: {"Widget,Widget\n", "CSV", []Term{{"Widget", "Widget"}}, false},
+	2: {"keep = [\"GO-TRAN\"]\n[terms]\nwidget = \"ウィジェット\"\n\"Apps Script\" = \"AS\"\n", "toml",
+		[]Term{{"GO-TRAN", ""}, {"Apps Script", "AS"}, {"widget", "ウィジェット"}}, false},
+	3: {"keep = [", "toml", nil, true},
+	4: {"\"a\n", "csv", nil, true},
+	5: {"widget,x\n", "xlsx", nil, true},
+}
+
+func TestReadGlossary(t *testing.T) {
+	for i, tt := range readglossarytests {
+		terms, err := ReadGlossary(strings.NewReader(tt.in), tt.kind)
+		if (err != nil) != tt.err {
+			t.Errorf("#%d ReadGlossary() have error: %v, want error: %v", i, err, tt.err)
+			continue
+		}
+		if fmt.Sprint(terms) != fmt.Sprint(tt.terms) {
+			t.Errorf("#%d ReadGlossary() = %q, want: %q", i, terms, tt.terms)
+		}
+	}
+}
+
+var glossaries = []*Glossary{
+	{Source: "en", Target: "ja", Terms: []Term{{"widget", "ウィジェット"}, {"GO-TRAN", ""}, {"Go", "Go言語"}}},
+	{Source: "", Target: "ja", Terms: []Term{{"Widget", "部品"}, {"C++", "シープラスプラス"}}},
+	{Source: "en", Target: "fr", Terms: []Term{{"widget", "gadget"}}},
+}
+
+type GlossaryTranslatorTest struct {
+	in     string
+	source string
+	target string
+	dict   dictTranslator
+	out    string
+}
+
+var glossarytranslatortests = []GlossaryTranslatorTest{
+	0: {"Hello", "en", "ja", dictTranslator{"Hello": "こんにちは"}, "こんにちは"},
+	1: {"Widgets and a widget", "en", "ja",
+		dictTranslator{"Widgets and a ⟦0⟧": "Widgetsと⟦0⟧"}, "Widgetsとウィジェット"},
+	2: {"GO-TRAN uses Go", "en", "ja",
+		dictTranslator{"⟦0⟧ uses ⟦1⟧": "⟦0⟧は⟦1⟧を使う"}, "GO-TRANはGo言語を使う"},
+	3: {"Go to <a href=\"go\">GO</a> ⟦0⟧", "en", "ja",
+		dictTranslator{"⟦0⟧ to <a href=\"go\">⟦1⟧</a> ⟦2⟧": "<a href=\"go\">⟦1⟧</a>へ⟦2⟧ ⟦0⟧"},
+		"<a href=\"go\">Go言語</a>へ⟦0⟧ Go言語"},
+	4: {"C++ widget", "", "ja",
+		dictTranslator{"⟦0⟧ ⟦1⟧": "⟦0⟧の⟦1⟧"}, "シープラスプラスのウィジェット"},
+	5: {"C++ widget", "de", "ja",
+		dictTranslator{"⟦0⟧ ⟦1⟧": "⟦0⟧の⟦1⟧"}, "シープラスプラスの部品"},
+	6: {"a widget", "en", "fr", dictTranslator{"a ⟦0⟧": "un ⟦0⟧"}, "un gadget"},
+	7: {"Going", "en", "ja", dictTranslator{"Going": "行く"}, "行く"},
+}
+
+func TestGlossaryTranslator_Translate(t *testing.T) {
+	for i, tt := range glossarytranslatortests {
+		g := NewGlossaryTranslator(tt.dict, glossaries...)
+		out, err := g.Translate(tt.in, tt.source, tt.target)
+		if err != nil {
+			t.Errorf("#%d Translate(%q) have error: %s, want error: nil", i, tt.in, err)
+			continue
+		}
+		if out != tt.out {
+			t.Errorf("#%d Translate(%q) = %q, want: %q", i, tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestGlossaryTranslator_Lost(t *testing.T) {
+	g := NewGlossaryTranslator(dictTranslator{"a ⟦0⟧": "un truc"}, glossaries...)
+	if _, err := g.Translate("a widget", "en", "ja"); err == nil {
+		t.Errorf("Translate() have error: nil, want: placeholders lost")
+	}
+}
+
+type GlossaryCheckTest struct {
+	source      string
+	translation string
+	violations  string
+}
+
+var glossarychecktests = []GlossaryCheckTest{
+	0: {"Hello", "こんにちは", "[]"},
+	1: {"a widget", "ウィジェット", "[]"},
+	2: {"a widget and widgets", "部品", `["widget" is not translated as "ウィジェット"]`},
+	3: {"Go-Tran and GO-TRAN", "go-tranとゴートラン", "[]"},
+	4: {"GO-TRAN", "ゴートラン", `["GO-TRAN" is translated, want: kept as it is]`},
+	5: {"{widget}", "{widget}", "[]"},
+}
+
+func TestGlossary_Check(t *testing.T) {
+	for i, tt := range glossarychecktests {
+		vs := glossaries[0].Check(tt.source, tt.translation)
+		if fmt.Sprint(vs) != tt.violations {
+			t.Errorf("#%d Check(%q, %q) = %v, want: %s",
+				i, tt.source, tt.translation, vs, tt.violations)
+		}
+	}
+	// The terms are compiled once.
+	ts := glossaries[0].terms
+	if glossaries[0].Check("a", "b"); ts == nil || glossaries[0].terms != ts {
+		t.Errorf("Check() compiled the terms again")
+	}
+}
@@ -5,6 +5,9 @@ go 1.15
 require (
 	github.com/BurntSushi/toml v0.3.1
 	github.com/mattn/go-isatty v0.0.12
+	github.com/mattn/go-runewidth v0.0.3
 	github.com/morikuni/aec v1.0.0
 	github.com/peterh/liner v1.2.0
+	golang.org/x/sys v0.0.0-20200116001909-b77594299b42
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -196,6 +196,85 @@ var iso639map = map[string]string{
 	"zu": "Zulu",
 }
 
+// autonyms holds the names of the languages in themselves, so that
+// the languages can be looked up by them without translation.
+var autonyms = map[string]string{
+	"af": "Afrikaans",
+	"am": "አማርኛ",
+	"ar": "العربية",
+	"az": "Azərbaycan dili",
+	"be": "Беларуская",
+	"bg": "Български",
+	"bn": "বাংলা",
+	"bs": "Bosanski",
+	"ca": "Català",
+	"cs": "Čeština",
+	"cy": "Cymraeg",
+	"da": "Dansk",
+	"de": "Deutsch",
+	"el": "Ελληνικά",
+	"en": "English",
+	"eo": "Esperanto",
+	"es": "Español",
+	"et": "Eesti",
+	"eu": "Euskara",
+	"fa": "فارسی",
+	"fi": "Suomi",
+	"fr": "Français",
+	"ga": "Gaeilge",
+	"gl": "Galego",
+	"gu": "ગુજરાતી",
+	"he": "עברית",
+	"hi": "हिन्दी",
+	"hr": "Hrvatski",
+	"hu": "Magyar",
+	"hy": "Հայերեն",
+	"id": "Bahasa Indonesia",
+	"is": "Íslenska",
+	"it": "Italiano",
+	"ja": "日本語",
+	"ka": "ქართული",
+	"kk": "Қазақ тілі",
+	"km": "ខ្មែរ",
+	"kn": "ಕನ್ನಡ",
+	"ko": "한국어",
+	"lo": "ລາວ",
+	"lt": "Lietuvių",
+	"lv": "Latviešu",
+	"mk": "Македонски",
+	"ml": "മലയാളം",
+	"mn": "Монгол",
+	"mr": "मराठी",
+	"ms": "Bahasa Melayu",
+	"mt": "Malti",
+	"my": "မြန်မာ",
+	"ne": "नेपाली",
+	"nl": "Nederlands",
+	"no": "Norsk",
+	"pa": "ਪੰਜਾਬੀ",
+	"pl": "Polski",
+	"pt": "Português",
+	"ro": "Română",
+	"ru": "Русский",
+	"si": "සිංහල",
+	"sk": "Slovenčina",
+	"sl": "Slovenščina",
+	"sq": "Shqip",
+	"sr": "Српски",
+	"sv": "Svenska",
+	"sw": "Kiswahili",
+	"ta": "தமிழ்",
+	"te": "తెలుగు",
+	"th": "ไทย",
+	"tl": "Tagalog",
+	"tr": "Türkçe",
+	"uk": "Українська",
+	"ur": "اردو",
+	"uz": "Oʻzbekcha",
+	"vi": "Tiếng Việt",
+	"zh": "中文",
+}
+
 type ISO639 struct {
 	Code string
 	Name string
@@ -233,15 +312,38 @@ var iso639Array = func() ISO639List {
 	return a
 }()
 
+// LookupLangCode returns the name of the language of an ISO639-1 code,
+// or of PseudoTarget.
 func LookupLangCode(s string) (code, name string, ok bool) {
 	code = strings.ToLower(strings.TrimSpace(s))
+	if code == PseudoTarget {
+		return code, PseudoName, true
+	}
 	name, ok = iso639map[code]
 	return
 }
 
 func lookupLangName(s string) (code, name string, ok bool) {
+	return iso639Array.lookupName(s)
+}
+
+func langListContains(substr string) []*ISO639 {
+	return iso639Array.contains(substr)
+}
+
+func (a ISO639List) lookupCode(s string) (code, name string, ok bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, lang := range a {
+		if strings.ToLower(lang.Code) == s {
+			return lang.Code, lang.Name, true
+		}
+	}
+	return "", "", false
+}
+
+func (a ISO639List) lookupName(s string) (code, name string, ok bool) {
 	s = strings.ToLower(strings.TrimSpace(s))
-	for _, lang := range iso639Array {
+	for _, lang := range a {
 		lname := strings.ToLower(lang.Name)
 		if strings.Contains(lname, s) {
 			return lang.Code, lang.Name, true
@@ -250,19 +352,43 @@ func lookupLangName(s string) (code, name string, ok bool) {
 	return "", "", false
 }
 
-func langListContains(substr string) []*ISO639 {
+// lookupAutonym is like lookupName, but looks up s in the autonyms of
+// the languages.
+func (a ISO639List) lookupAutonym(s string) (code, name string, ok bool) {
+	if b := a.containsAutonym(s); len(b) > 0 {
+		return b[0].Code, b[0].Name, true
+	}
+	return "", "", false
+}
+
+func (a ISO639List) containsAutonym(substr string) ISO639List {
 	substr = strings.ToLower(strings.TrimSpace(substr))
+	b := make(ISO639List, 0, len(a))
 	if len(substr) == 0 {
-		return iso639Array
+		return b
 	}
-	a := make([]*ISO639, 0, len(iso639Array))
-	for _, lang := range iso639Array {
+	for _, lang := range a {
+		if s, ok := autonyms[lang.Code]; ok &&
+			strings.Contains(strings.ToLower(s), substr) {
+			b = append(b, lang)
+		}
+	}
+	return b
+}
+
+func (a ISO639List) contains(substr string) ISO639List {
+	substr = strings.ToLower(strings.TrimSpace(substr))
+	if len(substr) == 0 {
+		return a
+	}
+	b := make(ISO639List, 0, len(a))
+	for _, lang := range a {
 		if strings.Contains(strings.ToLower(lang.Code), substr) ||
 			strings.Contains(strings.ToLower(lang.Name), substr) {
-			a = append(a, lang)
+			b = append(b, lang)
 		}
 	}
-	return a
+	return b
 }
 
 func AllLangList() ISO639List {
@@ -0,0 +1,170 @@
+package tran
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// LibreTranslate is a Translator which sends requests to a
+// LibreTranslate server.
+type LibreTranslate struct {
+	// URL is the base URL of the server, such as "http://localhost:5000".
+	URL string
+
+	// APIKey is sent with each request if not empty.
+	APIKey  string
+	Options Options
+
+	mu    sync.Mutex
+	langs ISO639List
+}
+
+// NewLibreTranslate returns a LibreTranslate for the server at url.
+// A nil opts means the zero Options.
+func NewLibreTranslate(url, apiKey string, opts *Options) *LibreTranslate {
+	lt := &LibreTranslate{URL: strings.TrimRight(url, "/"), APIKey: apiKey}
+	if opts != nil {
+		lt.Options = *opts
+	}
+	return lt
+}
+
+var errNoDetection = errors.New("no language detected")
+
+type libreError struct {
+	Error string `json:"error"`
+}
+
+type libreDetection struct {
+	Confidence float64 `json:"confidence"`
+	Language   string  `json:"language"`
+}
+
+type libreTranslation struct {
+	TranslatedText   string          `json:"translatedText"`
+	DetectedLanguage *libreDetection `json:"detectedLanguage"`
+}
+
+type libreLanguage struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// call sends params as JSON to path of the server, and decodes the
+// response into v.
+func (lt *LibreTranslate) call(ctx context.Context, method, path string,
+	params map[string]string, v interface{}) error {
+	var body io.Reader
+	contentType := ""
+	if params != nil {
+		if lt.APIKey != "" {
+			params["api_key"] = lt.APIKey
+		}
+		buf, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+		contentType = "application/json"
+	}
+	resp, buf, err := lt.Options.send(ctx, method, lt.URL+path, contentType, body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var le libreError
+		if err := json.Unmarshal(buf, &le); err != nil || le.Error == "" {
+			return httpError(resp)
+		}
+		return &APIError{Code: resp.StatusCode, Message: le.Error}
+	}
+	if err := json.Unmarshal(buf, v); err != nil {
+		return invalidResponse(err)
+	}
+	return nil
+}
+
+func (lt *LibreTranslate) Translate(text, source, target string) (string, error) {
+	return lt.TranslateContext(context.Background(), text, source, target)
+}
+
+func (lt *LibreTranslate) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	r, err := lt.TranslateResult(ctx, text, source, target)
+	if err != nil {
+		return "", err
+	}
+	return r.Text, nil
+}
+
+// TranslateResult is like TranslateContext, but also returns the source
+// language detected by the server if source is empty.
+func (lt *LibreTranslate) TranslateResult(ctx context.Context, text, source, target string) (*Result, error) {
+	auto := source
+	if auto == "" {
+		auto = "auto"
+	}
+	var tr libreTranslation
+	err := lt.call(ctx, http.MethodPost, "/translate", map[string]string{
+		"q":      text,
+		"source": auto,
+		"target": target,
+		"format": "text",
+	}, &tr)
+	if err != nil {
+		return nil, err
+	}
+	r := &Result{Text: tr.TranslatedText, Source: source}
+	if r.Source == "" && tr.DetectedLanguage != nil {
+		r.Source = tr.DetectedLanguage.Language
+	}
+	return r, nil
+}
+
+// Languages returns the languages supported by the server. The list is
+// requested once and remembered.
+func (lt *LibreTranslate) Languages() (ISO639List, error) {
+	lt.mu.Lock()
+	defer lt.mu.Unlock()
+	if lt.langs != nil {
+		return lt.langs, nil
+	}
+	var ls []libreLanguage
+	if err := lt.call(context.Background(), http.MethodGet, "/languages", nil, &ls); err != nil {
+		return nil, err
+	}
+	a := make(ISO639List, 0, len(ls))
+	for _, l := range ls {
+		name := l.Name
+		if _, n, ok := LookupLangCode(l.Code); ok {
+			name = n
+		}
+		a = append(a, &ISO639{Code: l.Code, Name: name})
+	}
+	lt.langs = a
+	return a, nil
+}
+
+func (lt *LibreTranslate) Detect(text string) (string, error) {
+	var ds []libreDetection
+	err := lt.call(context.Background(), http.MethodPost, "/detect",
+		map[string]string{"q": text}, &ds)
+	if err != nil {
+		return "", err
+	}
+	if len(ds) == 0 {
+		return "", invalidResponse(errNoDetection)
+	}
+	best := ds[0]
+	for _, d := range ds[1:] {
+		if d.Confidence > best.Confidence {
+			best = d
+		}
+	}
+	return best.Language, nil
+}
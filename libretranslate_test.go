@@ -0,0 +1,115 @@
+package tran
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newLibreServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/translate", func(w http.ResponseWriter, r *http.Request) {
+		var p map[string]string
+		json.NewDecoder(r.Body).Decode(&p)
+		switch {
+		case p["api_key"] != "secret":
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(libreError{"Invalid API key"})
+		case p["target"] != "en":
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(libreError{p["target"] + " is not supported"})
+		case p["q"] == "quota":
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(libreError{"Slowdown: 10 per 1 minute"})
+		default:
+			json.NewEncoder(w).Encode(libreTranslation{
+				TranslatedText:   p["q"] + "@" + p["source"],
+				DetectedLanguage: &libreDetection{90, "ja"},
+			})
+		}
+	})
+	mux.HandleFunc("/languages", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]libreLanguage{
+			{"en", "English"}, {"ja", "Japanese"}, {"zt", "Chinese (traditional)"},
+		})
+	})
+	mux.HandleFunc("/detect", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]libreDetection{{40, "zh"}, {95, "ja"}})
+	})
+	return httptest.NewServer(mux)
+}
+
+type LibreTranslate_TranslateTest struct {
+	text   string
+	source string
+	target string
+	out    string
+	err    error
+}
+
+var libretranslate_translatetests = []LibreTranslate_TranslateTest{
+	0: {"猫", "", "en", "猫@auto", nil},
+	1: {"猫", "ja", "en", "猫@ja", nil},
+	2: {"猫", "", "xx", "", ErrUnsupportedLanguage},
+	3: {"quota", "", "en", "", ErrQuotaExceeded},
+}
+
+func TestLibreTranslate_Translate(t *testing.T) {
+	ts := newLibreServer(t)
+	defer ts.Close()
+	lt := NewLibreTranslate(ts.URL+"/", "secret", nil)
+	for i, tt := range libretranslate_translatetests {
+		out, err := lt.Translate(tt.text, tt.source, tt.target)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("#%d have error: %v, want error: %v", i, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || out != tt.out {
+			t.Errorf("#%d Translate(%q, %q, %q) = (%q, %v), want: (%q, nil)",
+				i, tt.text, tt.source, tt.target, out, err, tt.out)
+		}
+	}
+
+	r, err := lt.TranslateResult(context.Background(), "猫", "", "en")
+	if err != nil || r.Source != "ja" {
+		t.Errorf("TranslateResult() = (%+v, %v), want: source ja", r, err)
+	}
+
+	_, err = NewLibreTranslate(ts.URL, "", nil).Translate("猫", "", "en")
+	var ae *APIError
+	if !errors.As(err, &ae) || ae.Code != http.StatusForbidden {
+		t.Errorf("without api key: have error: %v, want error: *APIError{403}", err)
+	}
+}
+
+func TestLibreTranslate_Languages(t *testing.T) {
+	ts := newLibreServer(t)
+	defer ts.Close()
+	lt := NewLibreTranslate(ts.URL, "secret", nil)
+
+	a, err := lt.Languages()
+	if err != nil || a.String() != "[en:English ja:Japanese zt:Chinese (traditional)]" {
+		t.Errorf("Languages() = (%v, %v)", a, err)
+	}
+	code, name, ok := LookupLang(lt, "tradi")
+	if code != "zt" || name != "Chinese (traditional)" || !ok {
+		t.Errorf("LookupLang(%q) = (%q, %q, %v), want: (%q, %q, true)",
+			"tradi", code, name, ok, "zt", "Chinese (traditional)")
+	}
+	if _, _, ok := LookupLang(lt, "fr"); ok {
+		t.Errorf("LookupLang(%q) = ok, want: not ok", "fr")
+	}
+	if a := LangListContains(lt, "an"); a.String() != "[ja:Japanese]" {
+		t.Errorf("LangListContains(%q) = %v, want: [ja:Japanese]", "an", a)
+	}
+
+	code, err = lt.Detect("猫")
+	if err != nil || code != "ja" {
+		t.Errorf("Detect() = (%q, %v), want: (%q, nil)", code, err, "ja")
+	}
+}
@@ -0,0 +1,612 @@
+package tran
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryOptions configures a Memory.
+type MemoryOptions struct {
+	// Threshold is the minimum similarity of the fuzzy matches, from 0
+	// to 1. Zero or a negative number disables fuzzy matching.
+	Threshold float64
+
+	// MaxMatches limits the number of the fuzzy matches of a text.
+	MaxMatches int
+
+	// MaxEntries limits the number of entries. The oldest entries are
+	// evicted when the memory is saved. Zero means no limit.
+	MaxEntries int
+}
+
+// DefaultMemoryOptions is the MemoryOptions used when none is configured.
+var DefaultMemoryOptions = MemoryOptions{
+	Threshold:  0.75,
+	MaxMatches: 3,
+	MaxEntries: 100000,
+}
+
+// MemoryEntry is a translation unit stored in a Memory.
+type MemoryEntry struct {
+	Source     string
+	Target     string
+	Text       string
+	Translated string
+	Created    time.Time
+}
+
+func memoryKey(source, target, text string) [3]string {
+	return [3]string{source, target, text}
+}
+
+// Match is an entry of a Memory similar to a text.
+type Match struct {
+	Entry MemoryEntry
+	Score float64 // the similarity from 0 to 1
+}
+
+// Memory is a Translator which stores the translations of the
+// underlying Translator as a translation memory in a TMX file. The
+// translations of the same texts are reused, and the translations of
+// the similar texts are offered by OnFuzzy before the texts are
+// translated by the underlying Translator. Unlike a Cache, a Memory is
+// shared by all the backends and can be exchanged with other tools.
+type Memory struct {
+	Translator
+
+	// OnFuzzy is called with the fuzzy matches of a text to translate
+	// which has no exact match, if any.
+	OnFuzzy func(text string, matches []Match)
+
+	path string
+	opts MemoryOptions
+
+	mu      sync.Mutex
+	entries map[[3]string]*MemoryEntry
+	texts   map[string]map[string]*memoryText // by target and text
+	dirty   bool
+	now     func() time.Time
+}
+
+// memoryText is a text of the entries of a Memory to the same target
+// language.
+type memoryText struct {
+	runes   []rune
+	entries map[string]*MemoryEntry // by source
+}
+
+// OpenMemory returns a Memory of tr stored in the TMX file at path.
+// The file is created when the memory is saved if it does not exist.
+// A nil opts means DefaultMemoryOptions.
+func OpenMemory(tr Translator, path string, opts *MemoryOptions) (*Memory, error) {
+	m := &Memory{
+		Translator: tr,
+		path:       path,
+		opts:       DefaultMemoryOptions,
+		entries:    map[[3]string]*MemoryEntry{},
+		texts:      map[string]map[string]*memoryText{},
+		now:        time.Now,
+	}
+	if opts != nil {
+		m.opts = *opts
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := m.Import(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m.dirty = false
+	return m, nil
+}
+
+func (m *Memory) Translate(text, source, target string) (string, error) {
+	return m.TranslateContext(context.Background(), text, source, target)
+}
+
+func (m *Memory) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	r, err := m.TranslateResult(ctx, text, source, target)
+	if err != nil {
+		return "", err
+	}
+	return r.Text, nil
+}
+
+// TranslateResult is like TranslateContext, but also returns the source
+// language of the entry reused or detected by the underlying
+// Translator, and the fuzzy matches of text if it is translated. The
+// pseudo-localizations are stored only for PseudoTarget.
+func (m *Memory) TranslateResult(ctx context.Context, text, source, target string) (*Result, error) {
+	if e, ok := m.Lookup(text, source, target); ok {
+		return &Result{Text: e.Translated, Source: e.Source, Cached: true}, nil
+	}
+	a := m.Fuzzy(text, source, target)
+	if m.OnFuzzy != nil && len(a) > 0 {
+		m.OnFuzzy(text, a)
+	}
+	r, err := TranslateResult(ctx, m.Translator, text, source, target)
+	if err != nil {
+		return nil, err
+	}
+	if r.reusable(target) {
+		m.Store(text, r.Source, target, r.Text)
+	}
+	res := *r
+	res.Matches = a
+	return &res, nil
+}
+
+// Lookup returns the entry of the translation of text, if any. An
+// empty source matches any source language.
+func (m *Memory) Lookup(text, source, target string) (MemoryEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e, ok := m.entries[memoryKey(source, target, text)]; ok {
+		return *e, true
+	}
+	if source != "" {
+		return MemoryEntry{}, false
+	}
+	// The newest translation from any language is reused.
+	var found *MemoryEntry
+	if t, ok := m.texts[target][text]; ok {
+		for _, e := range t.entries {
+			if found == nil || e.Created.After(found.Created) ||
+				e.Created.Equal(found.Created) && e.Source < found.Source {
+				found = e
+			}
+		}
+	}
+	if found == nil {
+		return MemoryEntry{}, false
+	}
+	return *found, true
+}
+
+// Fuzzy returns the entries of the texts similar to text, most similar
+// and newest first. An empty source matches any source language.
+func (m *Memory) Fuzzy(text, source, target string) []Match {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.opts.Threshold <= 0 {
+		return nil
+	}
+	rs := []rune(text)
+	var a []Match
+	for _, t := range m.texts[target] {
+		score := similarity(rs, t.runes, m.opts.Threshold)
+		if score < m.opts.Threshold {
+			continue
+		}
+		for _, e := range t.entries {
+			if source == "" || e.Source == source {
+				a = append(a, Match{Entry: *e, Score: score})
+			}
+		}
+	}
+	sort.Slice(a, func(i, j int) bool {
+		switch {
+		case a[i].Score != a[j].Score:
+			return a[i].Score > a[j].Score
+		case !a[i].Entry.Created.Equal(a[j].Entry.Created):
+			return a[i].Entry.Created.After(a[j].Entry.Created)
+		case a[i].Entry.Text != a[j].Entry.Text:
+			return a[i].Entry.Text < a[j].Entry.Text
+		}
+		return a[i].Entry.Source < a[j].Entry.Source
+	})
+	if m.opts.MaxMatches > 0 && len(a) > m.opts.MaxMatches {
+		a = a[:m.opts.MaxMatches]
+	}
+	return a
+}
+
+// similarity returns the similarity of a and b by the edit distance, or
+// 0 if it is less than threshold. Since the similarity is at least
+// threshold only if the distance is at most k, the distance is computed
+// in the band of the width k around the diagonal, and given up as soon
+// as it exceeds k.
+func similarity(a, b []rune, threshold float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b) == 0 {
+		return 1
+	}
+	k := len(b)
+	if threshold > 0 {
+		// The epsilon is for the rounding errors, such as 1-0.9.
+		k = int((1-threshold)*float64(len(b)) + 1e-9)
+	}
+	if len(b)-len(a) > k {
+		return 0
+	}
+	// row[i] is the distance between a[:i] and b[:j], or more than k
+	// out of the band.
+	far := k + 1
+	row := make([]int, len(a)+1)
+	for i := range row {
+		row[i] = i
+		if i > k {
+			row[i] = far
+		}
+	}
+	for j := 1; j <= len(b); j++ {
+		lo, hi := max2(1, j-k), min2(len(a), j+k)
+		prev := row[lo-1]
+		if lo == 1 {
+			row[0] = j
+			if j > k {
+				row[0] = far
+			}
+		} else {
+			row[lo-1] = far
+		}
+		best := row[lo-1]
+		for i := lo; i <= hi; i++ {
+			d := prev
+			if a[i-1] != b[j-1] {
+				d = min3(prev, row[i-1], row[i]) + 1
+			}
+			if d > far {
+				d = far
+			}
+			prev, row[i] = row[i], d
+			best = min2(best, d)
+		}
+		if best > k {
+			return 0
+		}
+	}
+	if row[len(a)] > k {
+		return 0
+	}
+	return 1 - float64(row[len(a)])/float64(len(b))
+}
+
+func min2(a, b int) int {
+	if b < a {
+		return b
+	}
+	return a
+}
+
+func max2(a, b int) int {
+	if b > a {
+		return b
+	}
+	return a
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// Store adds the translation of text to the memory.
+func (m *Memory) Store(text, source, target, translated string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store(&MemoryEntry{
+		Source:     source,
+		Target:     target,
+		Text:       text,
+		Translated: translated,
+		Created:    m.now(),
+	})
+}
+
+func (m *Memory) store(e *MemoryEntry) {
+	if e.Text == "" || e.Translated == "" || e.Target == "" {
+		return
+	}
+	m.entries[memoryKey(e.Source, e.Target, e.Text)] = e
+	texts := m.texts[e.Target]
+	if texts == nil {
+		texts = map[string]*memoryText{}
+		m.texts[e.Target] = texts
+	}
+	t := texts[e.Text]
+	if t == nil {
+		t = &memoryText{runes: []rune(e.Text), entries: map[string]*MemoryEntry{}}
+		texts[e.Text] = t
+	}
+	t.entries[e.Source] = e
+	m.dirty = true
+}
+
+func (m *Memory) delete(e *MemoryEntry) {
+	delete(m.entries, memoryKey(e.Source, e.Target, e.Text))
+	t := m.texts[e.Target][e.Text]
+	if t == nil {
+		return
+	}
+	delete(t.entries, e.Source)
+	if len(t.entries) == 0 {
+		delete(m.texts[e.Target], e.Text)
+	}
+}
+
+// Clear removes all the entries of the memory.
+func (m *Memory) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = map[[3]string]*MemoryEntry{}
+	m.texts = map[string]map[string]*memoryText{}
+	m.dirty = true
+}
+
+// Entries returns the entries of the memory, oldest first.
+func (m *Memory) Entries() []*MemoryEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sortedEntries()
+}
+
+func (m *Memory) sortedEntries() []*MemoryEntry {
+	a := make([]*MemoryEntry, 0, len(m.entries))
+	for _, e := range m.entries {
+		a = append(a, e)
+	}
+	sort.Slice(a, func(i, j int) bool {
+		if !a[i].Created.Equal(a[j].Created) {
+			return a[i].Created.Before(a[j].Created)
+		}
+		ki, kj := memoryKey(a[i].Source, a[i].Target, a[i].Text), memoryKey(a[j].Source, a[j].Target, a[j].Text)
+		return strings.Join(ki[:], "\x00") < strings.Join(kj[:], "\x00")
+	})
+	return a
+}
+
+// Len returns the number of the entries of the memory.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// Save writes the memory to its file if it has been changed, after
+// evicting the oldest entries beyond MaxEntries.
+func (m *Memory) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.dirty {
+		return nil
+	}
+	a := m.sortedEntries()
+	if m.opts.MaxEntries > 0 && len(a) > m.opts.MaxEntries {
+		for _, e := range a[:len(a)-m.opts.MaxEntries] {
+			m.delete(e)
+		}
+		a = a[len(a)-m.opts.MaxEntries:]
+	}
+
+	var buf bytes.Buffer
+	if err := writeTMX(&buf, a); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(m.path), ".memory-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	m.dirty = false
+	return nil
+}
+
+// Export writes the entries of the memory to w as TMX 1.4.
+func (m *Memory) Export(w io.Writer) error {
+	return writeTMX(w, m.Entries())
+}
+
+// Import adds the translation units of the TMX file r to the memory, and
+// returns the number of the entries added. A unit with the variants of
+// several languages adds the translations from the source language of
+// the unit or the file, or else from its first variant, to the others.
+func (m *Memory) Import(r io.Reader) (n int, err error) {
+	var doc tmx
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, tu := range doc.Units {
+		if len(tu.Variants) < 2 {
+			continue
+		}
+		srclang := tu.SrcLang
+		if srclang == "" || srclang == "*all*" {
+			srclang = doc.Header.SrcLang
+		}
+		src := 0
+		for i, v := range tu.Variants {
+			if strings.EqualFold(v.lang(), srclang) {
+				src = i
+				break
+			}
+		}
+		created, _ := time.Parse(tmxTime, tu.Created)
+		if created.IsZero() {
+			created = m.now()
+		}
+		for i, v := range tu.Variants {
+			if i == src {
+				continue
+			}
+			m.store(&MemoryEntry{
+				Source:     tmxLang(tu.Variants[src].lang()),
+				Target:     tmxLang(v.lang()),
+				Text:       tu.Variants[src].Seg.text(),
+				Translated: v.Seg.text(),
+				Created:    created.UTC(),
+			})
+			n++
+		}
+	}
+	return n, nil
+}
+
+const tmxTime = "20060102T150405Z"
+
+type tmx struct {
+	XMLName xml.Name  `xml:"tmx"`
+	Version string    `xml:"version,attr"`
+	Header  tmxHeader `xml:"header"`
+	Units   []tmxUnit `xml:"body>tu"`
+}
+
+type tmxHeader struct {
+	CreationTool    string `xml:"creationtool,attr"`
+	CreationVersion string `xml:"creationtoolversion,attr"`
+	SegType         string `xml:"segtype,attr"`
+	TMF             string `xml:"o-tmf,attr"`
+	AdminLang       string `xml:"adminlang,attr"`
+	SrcLang         string `xml:"srclang,attr"`
+	DataType        string `xml:"datatype,attr"`
+}
+
+type tmxUnit struct {
+	SrcLang  string       `xml:"srclang,attr,omitempty"`
+	Created  string       `xml:"creationdate,attr,omitempty"`
+	Variants []tmxVariant `xml:"tuv"`
+}
+
+type tmxVariant struct {
+	Lang    string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	OldLang string `xml:"lang,attr,omitempty"` // of TMX 1.1
+	Seg     tmxSeg `xml:"seg"`
+}
+
+func (v *tmxVariant) lang() string {
+	if v.Lang != "" {
+		return v.Lang
+	}
+	return v.OldLang
+}
+
+// tmxSeg is a segment, whose inline elements are kept as its innerxml.
+type tmxSeg struct {
+	Inner string `xml:",innerxml"`
+}
+
+// tmxCode is the inline elements of TMX holding native codes, whose
+// contents are not the text of the segment.
+var tmxCode = map[string]bool{"bpt": true, "ept": true, "ph": true, "it": true, "ut": true}
+
+// text returns the text of the segment without native codes.
+func (s tmxSeg) text() string {
+	var sb strings.Builder
+	dec := xml.NewDecoder(strings.NewReader(s.Inner))
+	skip := 0
+	for {
+		t, err := dec.Token()
+		if err != nil {
+			return sb.String()
+		}
+		switch t := t.(type) {
+		case xml.StartElement:
+			if skip > 0 || tmxCode[t.Name.Local] {
+				skip++
+			}
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+			}
+		case xml.CharData:
+			if skip == 0 {
+				sb.Write(t)
+			}
+		}
+	}
+}
+
+// tmxLang returns the language code of a TMX language, such as "ja" of
+// "ja-JP", as go-tran knows it, or "" of "und" (undetermined).
+func tmxLang(s string) string {
+	if strings.EqualFold(s, "und") {
+		return ""
+	}
+	if code, _, ok := LookupLangCode(s); ok {
+		return code
+	}
+	if i := strings.IndexAny(s, "-_"); i > 0 {
+		if code, _, ok := LookupLangCode(s[:i]); ok {
+			return code
+		}
+	}
+	return strings.ToLower(s)
+}
+
+func writeTMX(w io.Writer, entries []*MemoryEntry) error {
+	doc := tmx{
+		Version: "1.4",
+		Header: tmxHeader{
+			CreationTool:    "go-tran",
+			CreationVersion: "1",
+			SegType:         "sentence",
+			TMF:             "go-tran",
+			AdminLang:       "en",
+			SrcLang:         "*all*",
+			DataType:        "plaintext",
+		},
+	}
+	for _, e := range entries {
+		source := e.Source
+		if source == "" {
+			source = "und"
+		}
+		var seg [2]bytes.Buffer
+		xml.EscapeText(&seg[0], []byte(e.Text))
+		xml.EscapeText(&seg[1], []byte(e.Translated))
+		doc.Units = append(doc.Units, tmxUnit{
+			SrcLang: source,
+			Created: e.Created.UTC().Format(tmxTime),
+			Variants: []tmxVariant{
+				{Lang: source, Seg: tmxSeg{seg[0].String()}},
+				{Lang: e.Target, Seg: tmxSeg{seg[1].String()}},
+			},
+		})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
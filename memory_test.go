@@ -0,0 +1,263 @@
+package tran
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemory_Translate(t *testing.T) {
+	cachePath, cleanup := tempCachePath(t)
+	defer cleanup()
+	path := filepath.Join(filepath.Dir(cachePath), "memory.tmx")
+
+	tr := &countTranslator{}
+	m, err := OpenMemory(tr, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fuzzy []string
+	m.OnFuzzy = func(text string, a []Match) {
+		for _, x := range a {
+			fuzzy = append(fuzzy, fmt.Sprintf("%s~%s:%.2f", text, x.Entry.Text, x.Score))
+		}
+	}
+	for i, in := range []string{"the black cat", "the black cat", "the black cats", "a dog"} {
+		out, err := m.Translate(in, "en", "ja")
+		if want := strings.ToUpper(in) + "@ja"; err != nil || out != want {
+			t.Errorf("#%d Translate() = (%q, %v), want: (%q, nil)", i, out, err, want)
+		}
+	}
+	if tr.calls != 3 {
+		t.Errorf("have calls: %d, want: 3", tr.calls)
+	}
+	if want := "[the black cats~the black cat:0.93]"; fmt.Sprint(fuzzy) != want {
+		t.Errorf("have fuzzy: %v, want: %s", fuzzy, want)
+	}
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopened, and looked up from any source language
+	tr = &countTranslator{}
+	m, err = OpenMemory(tr, path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 3 {
+		t.Errorf("reopened: have entries: %d, want: 3", m.Len())
+	}
+	r, err := TranslateResult(context.Background(), m, "a dog", "", "ja")
+	if err != nil || r.Text != "A DOG@ja" || r.Source != "en" || !r.Cached || tr.calls != 0 {
+		t.Errorf("reopened: TranslateResult() = (%+v, %v) by %d calls, want: cached A DOG@ja from en by 0 calls",
+			r, err, tr.calls)
+	}
+	if out, _ := m.Translate("a dog", "de", "ja"); out != "A DOG@ja" || tr.calls != 1 {
+		t.Errorf("reopened: have calls: %d, want: 1", tr.calls)
+	}
+}
+
+type SimilarityTest struct {
+	a, b  string
+	score float64
+}
+
+var similaritytests = []SimilarityTest{
+	0: {"", "", 1},
+	1: {"abc", "abc", 1},
+	2: {"abcd", "abce", 0.75},
+	3: {"abcd", "abd", 0.75},
+	4: {"猫が好き", "犬が好き", 0.75},
+	5: {"ab", "abcdefgh", 0}, // too different in length
+	6: {"kitten", "sitting", 1 - 3.0/7},
+}
+
+func TestSimilarity(t *testing.T) {
+	for i, tt := range similaritytests {
+		score := similarity([]rune(tt.a), []rune(tt.b), 0.5)
+		if fmt.Sprintf("%.4f", score) != fmt.Sprintf("%.4f", tt.score) {
+			t.Errorf("#%d similarity(%q, %q) = %.4f, want: %.4f", i, tt.a, tt.b, score, tt.score)
+		}
+	}
+}
+
+// distance is the edit distance of a and b without any band.
+func distance(a, b []rune) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1])
+			if a[i-1] != b[j-1] {
+				d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func TestSimilarity_Band(t *testing.T) {
+	words := []string{"", "a", "ab", "ba", "abc", "kitten", "sitting", "the cat sat", "the hat sat on",
+		"猫が好き", "犬が好きです", "open the file", "open the files", "close the file", "abcdefghij", "abcdefghiX", "jihgfedcba"}
+	for _, threshold := range []float64{0.3, 0.5, 0.75, 0.9, 1} {
+		for _, x := range words {
+			for _, y := range words {
+				a, b := []rune(x), []rune(y)
+				n := len(a)
+				if len(b) > n {
+					n = len(b)
+				}
+				want := 1.0
+				if n > 0 {
+					want = 1 - float64(distance(a, b))/float64(n)
+				}
+				if want < threshold {
+					want = 0
+				}
+				if have := similarity(a, b, threshold); fmt.Sprintf("%.4f", have) != fmt.Sprintf("%.4f", want) {
+					t.Errorf("similarity(%q, %q, %g) = %.4f, want: %.4f", x, y, threshold, have, want)
+				}
+			}
+		}
+	}
+}
+
+func TestMemory_TranslateResult(t *testing.T) {
+	cachePath, cleanup := tempCachePath(t)
+	defer cleanup()
+	m, err := OpenMemory(&countTranslator{}, filepath.Join(filepath.Dir(cachePath), "memory.tmx"),
+		&MemoryOptions{Threshold: 0.5, MaxEntries: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { now = now.Add(time.Second); return now }
+	ctx := context.Background()
+	for _, s := range []string{"open the file", "close it", "open the files"} {
+		if _, err := TranslateResult(ctx, m, s, "en", "ja"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r, err := TranslateResult(ctx, m, "open the door", "en", "ja")
+	if err != nil || r.Cached || len(r.Matches) != 2 || r.Matches[0].Entry.Text != "open the file" {
+		t.Errorf("TranslateResult() = (%+v, %v), want: the matches of open the file(s)", r, err)
+	}
+	if r, _ := TranslateResult(ctx, m, "open the door", "", "ja"); !r.Cached || len(r.Matches) != 0 {
+		t.Errorf("TranslateResult() = %+v, want: cached without matches", r)
+	}
+
+	// The oldest are evicted from the index too.
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if e, ok := m.Lookup("open the file", "", "ja"); ok {
+		t.Errorf("Lookup() = %+v, want: evicted", e)
+	}
+	if a := m.Fuzzy("close it!", "", "ja"); len(a) != 0 {
+		t.Errorf("Fuzzy() = %+v, want: evicted", a)
+	}
+}
+
+func TestMemory_Fuzzy(t *testing.T) {
+	m, err := OpenMemory(dictTranslator{}, filepath.Join("testdata", "notexists.tmx"),
+		&MemoryOptions{Threshold: 0.5, MaxMatches: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { now = now.Add(time.Second); return now }
+	m.Store("open the file", "en", "ja", "ファイルを開く")
+	m.Store("open the files", "en", "ja", "ファイル群を開く")
+	m.Store("open the file", "en", "de", "Datei öffnen")
+	m.Store("close the file", "en", "ja", "ファイルを閉じる")
+	m.Store("open the door", "fr", "ja", "ドアを開く")
+
+	a := m.Fuzzy("open the fil", "en", "ja")
+	if len(a) != 2 || a[0].Entry.Translated != "ファイルを開く" || a[1].Entry.Translated != "ファイル群を開く" {
+		t.Errorf("Fuzzy() = %+v, want: ファイルを開く and ファイル群を開く", a)
+	}
+	if a := m.Fuzzy("open the door", "en", "ja"); len(a) != 2 || a[0].Entry.Text != "open the file" {
+		t.Errorf("Fuzzy() = %+v, want: open the file and open the files", a)
+	}
+	if a := m.Fuzzy("open the door", "", "ja"); len(a) != 2 || a[0].Score != 1 {
+		t.Errorf("Fuzzy() from any language = %+v, want: open the door and open the file", a)
+	}
+	if a := m.Fuzzy("something else", "en", "ja"); len(a) != 0 {
+		t.Errorf("Fuzzy() = %+v, want: none", a)
+	}
+}
+
+const tmxSample = `<?xml version="1.0" encoding="UTF-8"?>
+<tmx version="1.4">
+  <header creationtool="x" creationtoolversion="1" segtype="sentence" o-tmf="x"
+    adminlang="en" srclang="en-US" datatype="plaintext"/>
+  <body>
+    <tu creationdate="20200102T030405Z">
+      <tuv xml:lang="ja-JP"><seg>開く</seg></tuv>
+      <tuv xml:lang="en-US"><seg>Open</seg></tuv>
+      <tuv xml:lang="de-DE"><seg>Öffnen</seg></tuv>
+    </tu>
+    <tu srclang="fr">
+      <tuv lang="FR"><seg>Cliquez <bpt i="1">&lt;b&gt;</bpt>ici<ept i="1">&lt;/b&gt;</ept> &amp; ok</seg></tuv>
+      <tuv lang="en"><seg>Click <bpt i="1">&lt;b&gt;</bpt>here<ept i="1">&lt;/b&gt;</ept> &amp; ok</seg></tuv>
+    </tu>
+    <tu>
+      <tuv xml:lang="en"><seg>Alone</seg></tuv>
+    </tu>
+  </body>
+</tmx>
+`
+
+func TestMemory_Import(t *testing.T) {
+	m, err := OpenMemory(dictTranslator{}, filepath.Join("testdata", "notexists.tmx"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.now = func() time.Time { return time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC) }
+	n, err := m.Import(strings.NewReader(tmxSample))
+	if err != nil || n != 3 {
+		t.Fatalf("Import() = (%d, %v), want: (3, nil)", n, err)
+	}
+	entries := func(m *Memory) []string {
+		var a []string
+		for _, e := range m.Entries() {
+			a = append(a, fmt.Sprintf("%s>%s %s=%s %s", e.Source, e.Target, e.Text, e.Translated,
+				e.Created.Format("2006-01-02 15:04:05")))
+		}
+		return a
+	}
+	want := []string{
+		"en>de Open=Öffnen 2020-01-02 03:04:05",
+		"en>ja Open=開く 2020-01-02 03:04:05",
+		"fr>en Cliquez ici & ok=Click here & ok 2021-01-01 00:00:00",
+	}
+	if have := entries(m); fmt.Sprint(have) != fmt.Sprint(want) {
+		t.Errorf("Import() have entries: %q, want: %q", have, want)
+	}
+	if _, err := m.Import(strings.NewReader("<tmx>")); err == nil {
+		t.Errorf("Import(invalid) have error: nil, want: error")
+	}
+
+	// Exported and imported again
+	var buf bytes.Buffer
+	if err := m.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+	m2, _ := OpenMemory(dictTranslator{}, filepath.Join("testdata", "notexists.tmx"), nil)
+	if n, err := m2.Import(&buf); err != nil || n != 3 {
+		t.Fatalf("Import(exported) = (%d, %v), want: (3, nil)", n, err)
+	}
+	if have := entries(m2); fmt.Sprint(have) != fmt.Sprint(want) {
+		t.Errorf("Import(exported) have entries: %q, want: %q", have, want)
+	}
+}
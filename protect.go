@@ -0,0 +1,142 @@
+package tran
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultPlaceholders is the patterns of the placeholders and the
+// markup protected by a Protector: masks such as "⟦0⟧", Go template
+// actions, ${name} and %{name}, ICU and brace placeholders such as
+// {name} and {count, number}, Python format fields such as {} and
+// {0:.2f}, printf verbs, HTML tags and HTML entities.
+var DefaultPlaceholders = []*regexp.Regexp{
+	regexp.MustCompile(`⟦\s*\d+\s*⟧`),
+	regexp.MustCompile(`\{\{.*?\}\}`),
+	regexp.MustCompile(`[$%]\{[^{}\s]*\}`),
+	regexp.MustCompile(`\{\s*[\w.]+\s*(,\s*\w+\s*(,\s*[^{}]*)?)?\}`),
+	regexp.MustCompile(`\{[\w.]*(![rsa])?(:[^{}\s]*)?\}`),
+	regexp.MustCompile(`%(\[\d+\]|\d+\$|\(\w+\))?[-+#0]*(\d+|\*)?(\.(\d+|\*))?[bcdeEfFgGioOpqsStTuvxX%]`),
+	regexp.MustCompile(`</?[a-zA-Z][\w:-]*(\s[^<>]*)?/?>`),
+	regexp.MustCompile(`&(#\d+|#[xX][0-9a-fA-F]+|[a-zA-Z]\w*);`),
+}
+
+// Placeholder matches any of DefaultPlaceholders.
+var Placeholder = alternate(DefaultPlaceholders)
+
+// PlaceholderError is returned by a Protector when placeholders are
+// lost in the translation.
+type PlaceholderError struct {
+	Lost []string
+}
+
+func (e *PlaceholderError) Error() string {
+	a := make([]string, len(e.Lost))
+	for i, s := range e.Lost {
+		a[i] = strconv.Quote(s)
+	}
+	return "placeholders lost in translation: " + strings.Join(a, ", ")
+}
+
+// Protector is a Translator which protects the placeholders and the
+// markup of texts from the underlying Translator, which may translate,
+// break or drop them. They are replaced with masks such as "⟦0⟧" before
+// the translation, and restored after it. If a mask is lost, the
+// translation fails with a PlaceholderError.
+type Protector struct {
+	Translator
+
+	pattern *regexp.Regexp
+}
+
+// NewProtector returns a Protector which protects the placeholders
+// matched by DefaultPlaceholders and patterns, in order of priority.
+func NewProtector(tr Translator, patterns ...*regexp.Regexp) *Protector {
+	patterns = append(append([]*regexp.Regexp{}, DefaultPlaceholders...), patterns...)
+	return &Protector{Translator: tr, pattern: alternate(patterns)}
+}
+
+// alternate returns the regular expression matching any of patterns, in
+// order of priority.
+func alternate(patterns []*regexp.Regexp) *regexp.Regexp {
+	a := make([]string, len(patterns))
+	for i, re := range patterns {
+		a[i] = "(?:" + re.String() + ")"
+	}
+	return regexp.MustCompile(strings.Join(a, "|"))
+}
+
+func (p *Protector) Translate(text, source, target string) (string, error) {
+	return p.TranslateContext(context.Background(), text, source, target)
+}
+
+func (p *Protector) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	res, err := p.TranslateResult(ctx, text, source, target)
+	if err != nil {
+		return "", err
+	}
+	return res.Text, nil
+}
+
+func (p *Protector) TranslateResult(ctx context.Context, text, source, target string) (*Result, error) {
+	masked, masks := Mask(text, p.pattern)
+	res, err := TranslateResult(ctx, p.Translator, masked, source, target)
+	if err != nil || len(masks) == 0 {
+		return res, err
+	}
+	s, err := Unmask(res.Text, masks)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Text: s, Source: res.Source, Cached: res.Cached, Matches: res.Matches, Pseudo: res.Pseudo}, nil
+}
+
+// maskPattern matches the masks, which translators keep as they are.
+var maskPattern = regexp.MustCompile(`⟦\s*(\d+)\s*⟧`)
+
+// MaskToken returns the mask of the i-th piece masked, such as "⟦0⟧".
+func MaskToken(i int) string {
+	return fmt.Sprintf("⟦%d⟧", i)
+}
+
+// Mask replaces the pieces of s matched by re with masks, and returns
+// them.
+func Mask(s string, re *regexp.Regexp) (masked string, masks []string) {
+	masked = re.ReplaceAllStringFunc(s, func(m string) string {
+		masks = append(masks, m)
+		return MaskToken(len(masks) - 1)
+	})
+	return masked, masks
+}
+
+// StripMasks returns s without the masks.
+func StripMasks(s string) string {
+	return maskPattern.ReplaceAllString(s, "")
+}
+
+// Unmask restores the pieces masked in s. It returns a
+// *PlaceholderError if the masks of some of them are lost.
+func Unmask(s string, masks []string) (string, error) {
+	used := make([]bool, len(masks))
+	s = maskPattern.ReplaceAllStringFunc(s, func(m string) string {
+		i, err := strconv.Atoi(maskPattern.FindStringSubmatch(m)[1])
+		if err != nil || i >= len(masks) {
+			return m
+		}
+		used[i] = true
+		return masks[i]
+	})
+	var lost []string
+	for i, m := range masks {
+		if !used[i] {
+			lost = append(lost, m)
+		}
+	}
+	if lost != nil {
+		return "", &PlaceholderError{Lost: lost}
+	}
+	return s, nil
+}
@@ -0,0 +1,100 @@
+package tran
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+type ProtectorTest struct {
+	in     string
+	dict   dictTranslator
+	out    string
+	lost   []string
+	extras []*regexp.Regexp
+}
+
+var protectortests = []ProtectorTest{
+	0: {"Hello", dictTranslator{"Hello": "Bonjour"}, "Bonjour", nil, nil},
+	1: {"Hello {name}, you have %d items",
+		dictTranslator{"Hello ⟦0⟧, you have ⟦1⟧ items": "⟦0⟧さん、⟦ 1 ⟧個あります"},
+		"{name}さん、%d個あります", nil, nil},
+	2: {"<b>{{.Count}}</b> new &amp; ${user}",
+		dictTranslator{"⟦0⟧⟦1⟧⟦2⟧ new ⟦3⟧ ⟦4⟧": "⟦4⟧ ⟦0⟧⟦1⟧⟦2⟧ nouveaux ⟦3⟧"},
+		"${user} <b>{{.Count}}</b> nouveaux &amp;", nil, nil},
+	3: {"{count, number} of %[1]s and 100% sure",
+		dictTranslator{"⟦0⟧ of ⟦1⟧ and 100% sure": "⟦1⟧ no ⟦0⟧"},
+		"%[1]s no {count, number}", nil, nil},
+	4: {"Hi %s, bye %(name)s",
+		dictTranslator{"Hi ⟦0⟧, bye ⟦1⟧": "Salut, au revoir ⟦1⟧"},
+		"", []string{"%s"}, nil},
+	5: {"Press :ok: now",
+		dictTranslator{"Press ⟦0⟧ now": "Drücke jetzt ⟦0⟧"},
+		"Drücke jetzt :ok:", nil, []*regexp.Regexp{regexp.MustCompile(`:\w+:`)}},
+	6: {"Keep ⟦0⟧ and %s",
+		dictTranslator{"Keep ⟦0⟧ and ⟦1⟧": "⟦1⟧ et ⟦0⟧"},
+		"%s et ⟦0⟧", nil, nil},
+	7: {"{} of {0:.2f} and {name!r}",
+		dictTranslator{"⟦0⟧ of ⟦1⟧ and ⟦2⟧": "⟦2⟧ : ⟦1⟧ / ⟦0⟧"},
+		"{name!r} : {0:.2f} / {}", nil, nil},
+}
+
+func TestProtector_Translate(t *testing.T) {
+	for i, tt := range protectortests {
+		p := NewProtector(tt.dict, tt.extras...)
+		out, err := p.Translate(tt.in, "", "ja")
+		if tt.lost != nil {
+			var pe *PlaceholderError
+			if !errors.As(err, &pe) || fmt.Sprint(pe.Lost) != fmt.Sprint(tt.lost) {
+				t.Errorf("#%d Translate(%q) have error: %v, want: lost %q", i, tt.in, err, tt.lost)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d Translate(%q) have error: %s, want error: nil", i, tt.in, err)
+			continue
+		}
+		if out != tt.out {
+			t.Errorf("#%d Translate(%q) = %q, want: %q", i, tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestPlaceholderError(t *testing.T) {
+	err := &PlaceholderError{Lost: []string{"%s", "{n}"}}
+	want := `placeholders lost in translation: "%s", "{n}"`
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want: %q", err.Error(), want)
+	}
+}
+
+type UnmaskTest struct {
+	in    string
+	masks []string
+	out   string
+	lost  []string
+}
+
+var unmasktests = []UnmaskTest{
+	0: {"a ⟦0⟧ b ⟦1⟧", []string{"`x`", "<br>"}, "a `x` b <br>", nil},
+	1: {"a ⟦ 1 ⟧ b ⟦0⟧", []string{"`x`", "<br>"}, "a <br> b `x`", nil},
+	2: {"a b", []string{"`x`"}, "", []string{"`x`"}},
+	3: {"a ⟦7⟧ ⟦1⟧", []string{"`x`", "<br>"}, "", []string{"`x`"}},
+}
+
+func TestUnmask(t *testing.T) {
+	for i, tt := range unmasktests {
+		out, err := Unmask(tt.in, tt.masks)
+		var pe *PlaceholderError
+		if tt.lost != nil {
+			if !errors.As(err, &pe) || fmt.Sprintf("%q", pe.Lost) != fmt.Sprintf("%q", tt.lost) {
+				t.Errorf("#%d Unmask(%q, %q) have error: %v, want: %q lost", i, tt.in, tt.masks, err, tt.lost)
+			}
+			continue
+		}
+		if err != nil || out != tt.out {
+			t.Errorf("#%d Unmask(%q, %q) = (%q, %v), want: (%q, nil)", i, tt.in, tt.masks, out, err, tt.out)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package tran
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// PseudoTarget is the reserved language code of pseudo-localization,
+// which can be specified as the target instead of an ISO639-1 code.
+const PseudoTarget = "qps"
+
+// PseudoName is the language name of PseudoTarget.
+const PseudoName = "Pseudo"
+
+// Pseudo is a Translator which pseudo-localizes texts without network,
+// to test the layouts of user interfaces. Each line is bracketed, its
+// letters are replaced with accented ones, and it is lengthened by
+// Expansion. The placeholders matched by Placeholder, such as "%s",
+// "{name}" and "<b>", are kept as they are. The source and the target language are ignored.
+type Pseudo struct {
+	// Expansion is the ratio by which lines are lengthened,
+	// such as 0.3 for 30%.
+	Expansion float64
+}
+
+// NewPseudo returns a Pseudo which lengthens lines by 30%.
+func NewPseudo() *Pseudo {
+	return &Pseudo{Expansion: 0.3}
+}
+
+var pseudoLetters = map[rune]rune{
+	'a': 'à', 'b': 'ƀ', 'c': 'ç', 'd': 'ð', 'e': 'é', 'f': 'ƒ', 'g': 'ĝ',
+	'h': 'ĥ', 'i': 'î', 'j': 'ĵ', 'k': 'ķ', 'l': 'ļ', 'm': 'ɱ', 'n': 'ñ',
+	'o': 'ö', 'p': 'þ', 'q': 'ǫ', 'r': 'ŕ', 's': 'š', 't': 'ţ', 'u': 'û',
+	'v': 'ṽ', 'w': 'ŵ', 'x': 'ẋ', 'y': 'ý', 'z': 'ž',
+	'A': 'Å', 'B': 'Ɓ', 'C': 'Ç', 'D': 'Ð', 'E': 'É', 'F': 'Ƒ', 'G': 'Ĝ',
+	'H': 'Ĥ', 'I': 'Î', 'J': 'Ĵ', 'K': 'Ķ', 'L': 'Ļ', 'M': 'Ṁ', 'N': 'Ñ',
+	'O': 'Ö', 'P': 'Þ', 'Q': 'Ǫ', 'R': 'Ŕ', 'S': 'Š', 'T': 'Ţ', 'U': 'Û',
+	'V': 'Ṽ', 'W': 'Ŵ', 'X': 'Ẋ', 'Y': 'Ý', 'Z': 'Ž',
+}
+
+func (p *Pseudo) Translate(text, source, target string) (string, error) {
+	return p.TranslateContext(context.Background(), text, source, target)
+}
+
+func (p *Pseudo) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = p.line(line)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// line pseudo-localizes a line, keeping its leading and trailing spaces.
+func (p *Pseudo) line(s string) string {
+	body := strings.TrimSpace(s)
+	if body == "" {
+		return s
+	}
+	start := strings.Index(s, body)
+	lead, trail := s[:start], s[start+len(body):]
+
+	var sb strings.Builder
+	sb.WriteString(lead)
+	sb.WriteString("[")
+	n := 0
+	last := 0
+	for _, m := range Placeholder.FindAllStringIndex(body, -1) {
+		n += accent(&sb, body[last:m[0]])
+		sb.WriteString(body[m[0]:m[1]])
+		last = m[1]
+	}
+	n += accent(&sb, body[last:])
+	if pad := int(math.Ceil(float64(n) * p.Expansion)); pad > 0 {
+		sb.WriteString(" ")
+		sb.WriteString(strings.Repeat("~", pad))
+	}
+	sb.WriteString("]")
+	sb.WriteString(trail)
+	return sb.String()
+}
+
+// accent writes s to sb with its letters accented, and returns the
+// number of the characters other than spaces.
+func accent(sb *strings.Builder, s string) int {
+	n := 0
+	for _, r := range s {
+		if a, ok := pseudoLetters[r]; ok {
+			r = a
+		}
+		if !unicode.IsSpace(r) {
+			n++
+		}
+		sb.WriteRune(r)
+	}
+	return n
+}
+
+// TranslateResult is like TranslateContext, but returns the Result
+// reporting that it is pseudo-localized.
+func (p *Pseudo) TranslateResult(ctx context.Context, text, source, target string) (*Result, error) {
+	out, err := p.TranslateContext(ctx, text, source, target)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Text: out, Source: source, Pseudo: true}, nil
+}
+
+func (p *Pseudo) Languages() (ISO639List, error) {
+	return AllLangList(), nil
+}
+
+// Detect detects the language of text by Detect of this package, since
+// pseudo-localization knows no languages.
+func (p *Pseudo) Detect(text string) (string, error) {
+	d, ok := Detect(text)
+	if !ok {
+		return "", ErrNotSupported
+	}
+	return d.Code, nil
+}
+
+// PseudoRouter is a Translator which pseudo-localizes the texts to
+// PseudoTarget by Pseudo, and translates the others by the underlying
+// Translator. As the innermost backend, it lets pseudo-localization go
+// through the same wrappers as the translations.
+type PseudoRouter struct {
+	Translator
+	Pseudo *Pseudo
+}
+
+// NewPseudoRouter returns a PseudoRouter of tr and NewPseudo.
+func NewPseudoRouter(tr Translator) *PseudoRouter {
+	return &PseudoRouter{Translator: tr, Pseudo: NewPseudo()}
+}
+
+func (r *PseudoRouter) Translate(text, source, target string) (string, error) {
+	return r.TranslateContext(context.Background(), text, source, target)
+}
+
+func (r *PseudoRouter) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	res, err := r.TranslateResult(ctx, text, source, target)
+	if err != nil {
+		return "", err
+	}
+	return res.Text, nil
+}
+
+func (r *PseudoRouter) TranslateResult(ctx context.Context, text, source, target string) (*Result, error) {
+	if target == PseudoTarget {
+		return TranslateResult(ctx, r.Pseudo, text, source, target)
+	}
+	return TranslateResult(ctx, r.Translator, text, source, target)
+}
@@ -0,0 +1,87 @@
+package tran
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+type PseudoTest struct {
+	in  string
+	out string
+}
+
+var pseudotests = []PseudoTest{
+	0: {"", ""},
+	1: {"Hello", "[Ĥéļļö ~~]"},
+	2: {"Hi %s, %d left", "[Ĥî %s, %d ļéƒţ ~~~]"},
+	3: {"{name} has <b>{{.N}}</b> items", "[{name} ĥàš <b>{{.N}}</b> îţéɱš ~~~]"},
+	4: {"a\n\n  b  \n", "[à ~]\n\n  [ƀ ~]  \n"},
+	5: {"100%% &amp; ${x}", "[100%% &amp; ${x} ~]"},
+}
+
+func TestPseudo_Translate(t *testing.T) {
+	p := NewPseudo()
+	for i, tt := range pseudotests {
+		out, err := p.Translate(tt.in, "en", PseudoTarget)
+		if err != nil || out != tt.out {
+			t.Errorf("#%d Translate(%q) = (%q, %v), want: (%q, nil)", i, tt.in, out, err, tt.out)
+		}
+	}
+}
+
+func TestLookupLang_Pseudo(t *testing.T) {
+	code, name, ok := LookupLang(NewPseudo(), "QPS")
+	if code != PseudoTarget || name != PseudoName || !ok {
+		t.Errorf("LookupLang(%q) = (%q, %q, %v), want: (%q, %q, true)",
+			"QPS", code, name, ok, PseudoTarget, PseudoName)
+	}
+	code, name, ok = LookupLangCode("qps")
+	if code != PseudoTarget || name != PseudoName || !ok {
+		t.Errorf("LookupLangCode(%q) = (%q, %q, %v), want: (%q, %q, true)",
+			"qps", code, name, ok, PseudoTarget, PseudoName)
+	}
+}
+
+func TestPseudoRouter_Glossary(t *testing.T) {
+	g := &Glossary{Source: "en", Target: PseudoTarget, Terms: []Term{{Source: "GO-TRAN"}}}
+	tr := NewGlossaryTranslator(NewProtector(NewPseudoRouter(dictTranslator{"Hi": "Salut"}), regexp.MustCompile(`:\w+:`)), g)
+	if out, err := tr.Translate("Hi", "en", "fr"); err != nil || out != "Salut" {
+		t.Errorf("Translate(%q, fr) = (%q, %v), want: (%q, nil)", "Hi", out, err, "Salut")
+	}
+	// The term and the placeholder are protected from pseudo-localization.
+	out, err := tr.Translate("Use GO-TRAN :smile:", "en", PseudoTarget)
+	if want := "[Ûšé GO-TRAN :smile: ~]"; err != nil || out != want {
+		t.Errorf("Translate(qps) = (%q, %v), want: (%q, nil)", out, err, want)
+	}
+}
+
+func TestPseudo_NotStored(t *testing.T) {
+	cachePath, cleanup := tempCachePath(t)
+	defer cleanup()
+	m, err := OpenMemory(NewProtector(NewPseudo()), filepath.Join(filepath.Dir(cachePath), "memory.tmx"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := OpenCache(m, cachePath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{"ja", PseudoTarget} {
+		if _, err := c.Translate("Hello", "en", target); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := c.Lookup("Hello", "en", "ja"); ok {
+		t.Errorf("Cache.Lookup(ja) have: ok, want: pseudo-localization not cached")
+	}
+	if _, ok := m.Lookup("Hello", "en", "ja"); ok {
+		t.Errorf("Memory.Lookup(ja) have: ok, want: pseudo-localization not stored")
+	}
+	if out, ok := c.Lookup("Hello", "en", PseudoTarget); !ok || out != "[Ĥéļļö ~~]" {
+		t.Errorf("Cache.Lookup(qps) = (%q, %v), want: (%q, true)", out, ok, "[Ĥéļļö ~~]")
+	}
+	if _, ok := m.Lookup("Hello", "en", PseudoTarget); !ok {
+		t.Errorf("Memory.Lookup(qps) have: not ok, want: stored")
+	}
+}
@@ -0,0 +1,149 @@
+package tran
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RetryPolicy specifies how often and how long a Retrier waits
+// before it retries a failed translation.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries after the first
+	// attempt. Zero or a negative number disables retries.
+	MaxRetries int
+
+	// MinBackoff is the wait before the first retry. It doubles on
+	// each subsequent retry up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the RetryPolicy used when none is configured.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 30 * time.Second,
+}
+
+// Retrier is a Translator which retries the translations of the
+// underlying Translator failed with a transient error.
+type Retrier struct {
+	Translator
+	Policy RetryPolicy
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRetrier returns a Retrier which retries tr according to p.
+func NewRetrier(tr Translator, p RetryPolicy) *Retrier {
+	return &Retrier{Translator: tr, Policy: p}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (r *Retrier) Translate(text, source, target string) (string, error) {
+	return r.TranslateContext(context.Background(), text, source, target)
+}
+
+func (r *Retrier) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	res, err := r.TranslateResult(ctx, text, source, target)
+	if err != nil {
+		return "", err
+	}
+	return res.Text, nil
+}
+
+func (r *Retrier) TranslateResult(ctx context.Context, text, source, target string) (*Result, error) {
+	for i := 0; ; i++ {
+		res, err := TranslateResult(ctx, r.Translator, text, source, target)
+		if err == nil || i >= r.Policy.MaxRetries || ctx.Err() != nil {
+			return res, err
+		}
+		retryable, wait := classify(err)
+		if !retryable {
+			return nil, err
+		}
+		if d := r.backoff(i); d > wait {
+			wait = d
+		}
+		sleep := r.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if serr := sleep(ctx, wait); serr != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff returns the wait before the retry after the i-th failure,
+// exponentially increased and jittered in [d/2, d].
+func (r *Retrier) backoff(i int) time.Duration {
+	d := r.Policy.MinBackoff
+	for ; i > 0 && d < r.Policy.MaxBackoff; i-- {
+		d *= 2
+	}
+	if r.Policy.MaxBackoff > 0 && d > r.Policy.MaxBackoff {
+		d = r.Policy.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// IsRetryable reports whether err is a transient error which may
+// succeed if the translation is retried.
+func IsRetryable(err error) bool {
+	retryable, _ := classify(err)
+	return retryable
+}
+
+// classify reports whether err is retryable, and the minimum wait
+// required by the server before the retry.
+func classify(err error) (retryable bool, wait time.Duration) {
+	if errors.Is(err, context.Canceled) {
+		return false, 0
+	}
+	var he *HTTPError
+	if errors.As(err, &he) {
+		switch he.StatusCode {
+		case http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true, he.RetryAfter
+		}
+		return false, 0
+	}
+	var ae *APIError
+	if errors.As(err, &ae) {
+		if errors.Is(ae, ErrQuotaExceeded) {
+			// The daily quota is not restored by waiting a while.
+			msg := strings.ToLower(ae.Message)
+			return !strings.Contains(msg, "for one day"), 0
+		}
+		return ae.Code >= 500, 0
+	}
+	var ne net.Error
+	if errors.As(err, &ne) {
+		return true, 0
+	}
+	return false, 0
+}
@@ -0,0 +1,78 @@
+package tran
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/y-bash/go-tran/trantest"
+)
+
+func httpReply(status int) trantest.Reply {
+	return trantest.Reply{Status: status}
+}
+
+func apiReply(code int, msg string) trantest.Reply {
+	return trantest.Reply{Response: trantest.Response{Code: code, Message: msg}}
+}
+
+var retryAfter2 = trantest.Reply{
+	Status: http.StatusTooManyRequests,
+	Header: http.Header{"Retry-After": {"2"}},
+}
+
+type RetrierTest struct {
+	replies []trantest.Reply // followed by a successful reply
+	calls   int
+	err     bool
+	waits   []time.Duration // in milliseconds
+}
+
+var retriertests = []RetrierTest{
+	0: {[]trantest.Reply{}, 1, false, []time.Duration{}},
+	1: {[]trantest.Reply{httpReply(503), httpReply(502)}, 3, false, []time.Duration{10, 20}},
+	2: {[]trantest.Reply{httpReply(503), httpReply(503), httpReply(503), httpReply(503)},
+		4, true, []time.Duration{10, 20, 40}},
+	3: {[]trantest.Reply{httpReply(404)}, 1, true, []time.Duration{}},
+	4: {[]trantest.Reply{apiReply(500, "Exception: Internal error")}, 2, false, []time.Duration{10}},
+	5: {[]trantest.Reply{apiReply(400, "Exception: Invalid argument: target")}, 1, true, []time.Duration{}},
+	6: {[]trantest.Reply{apiReply(400, "Exception: Service invoked too many times in a short time")},
+		2, false, []time.Duration{10}},
+	7: {[]trantest.Reply{apiReply(400, "Exception: Service invoked too many times for one day")},
+		1, true, []time.Duration{}},
+	8: {[]trantest.Reply{retryAfter2}, 2, false, []time.Duration{2000}},
+}
+
+func TestRetrier_TranslateContext(t *testing.T) {
+	s := newAPIServer()
+	defer s.Close()
+	for i, tt := range retriertests {
+		s.Reset()
+		s.Script(tt.replies...)
+		waits := []time.Duration{}
+		r := NewRetrier(NewAPI(s.URL), RetryPolicy{3, 10 * time.Millisecond, 40 * time.Millisecond})
+		r.sleep = func(ctx context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		}
+		_, err := r.Translate("Cat", "", "ja")
+		if (err != nil) != tt.err {
+			t.Errorf("#%d have error: %v, want error: %v", i, err, tt.err)
+		}
+		if calls := len(s.Requests()); calls != tt.calls {
+			t.Errorf("#%d have calls: %d, want calls: %d", i, calls, tt.calls)
+		}
+		if len(waits) != len(tt.waits) {
+			t.Errorf("#%d have waits: %v, want waits: %v", i, waits, tt.waits)
+			continue
+		}
+		for j, d := range waits {
+			max := tt.waits[j] * time.Millisecond
+			if d < max/2 || d > max {
+				t.Errorf("#%d [%d] have wait: %v, want wait: [%v, %v]",
+					i, j, d, max/2, max)
+			}
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package tran
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Chunk is a part of a text translated at once, which is made of whole
+// paragraphs, or else of whole sentences where possible.
+type Chunk struct {
+	// Text is the text to translate, without the spaces around it. It
+	// is empty only for the first chunk of a text beginning with
+	// spaces.
+	Text string
+
+	// Sep is the spaces following Text, which are kept as they are.
+	Sep string
+}
+
+// SplitChunks splits text into chunks of at most limit characters,
+// which are joined with their separators into text again. The chunks
+// break at paragraphs, which are separated by blank lines, and else at
+// sentences, at spaces or anywhere, in order of priority.
+func SplitChunks(text string, limit int) []Chunk {
+	if limit <= 0 {
+		limit = 1
+	}
+	var chunks []Chunk
+	body := strings.TrimLeftFunc(text, unicode.IsSpace)
+	if lead := text[:len(text)-len(body)]; lead != "" {
+		chunks = append(chunks, Chunk{Sep: lead})
+	}
+
+	var units []Chunk
+	for _, p := range paragraphs(body) {
+		if utf8.RuneCountInString(p.Text) <= limit {
+			units = append(units, p)
+			continue
+		}
+		for _, s := range split(p.Text, sentenceEnds(p.Text)) {
+			units = append(units, words(s, limit)...)
+		}
+		units[len(units)-1].Sep = p.Sep
+	}
+
+	// The units are packed into as few chunks as possible.
+	var cur Chunk
+	n := 0
+	for _, u := range units {
+		if m := utf8.RuneCountInString(cur.Sep + u.Text); cur.Text != "" && n+m <= limit {
+			cur.Text += cur.Sep + u.Text
+			cur.Sep = u.Sep
+			n += m
+			continue
+		}
+		if cur.Text != "" {
+			chunks = append(chunks, cur)
+		}
+		cur, n = u, utf8.RuneCountInString(u.Text)
+	}
+	if cur.Text != "" {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
+
+// ChunkReader reads the chunks of a text from a reader, split as by
+// SplitChunks, as soon as the text following them is read.
+type ChunkReader struct {
+	r      *bufio.Reader
+	limit  int
+	rest   string  // read but not split yet
+	chunks []Chunk // split but not returned yet
+	err    error
+}
+
+// NewChunkReader returns a ChunkReader reading from r, whose chunks are
+// of at most limit characters.
+func NewChunkReader(r io.Reader, limit int) *ChunkReader {
+	return &ChunkReader{r: bufio.NewReader(r), limit: limit}
+}
+
+// Next returns the next chunk, or io.EOF at the end of the text.
+func (c *ChunkReader) Next() (Chunk, error) {
+	for len(c.chunks) == 0 {
+		if c.err != nil {
+			return Chunk{}, c.err
+		}
+		line, err := c.r.ReadString('\n')
+		c.rest += line
+		if err != nil {
+			c.chunks, c.rest, c.err = SplitChunks(c.rest, c.limit), "", err
+			continue
+		}
+		if utf8.RuneCountInString(c.rest) <= c.limit {
+			continue
+		}
+		// The last chunk may go on in the lines not read yet.
+		chunks := SplitChunks(c.rest, c.limit)
+		last := chunks[len(chunks)-1]
+		c.chunks, c.rest = chunks[:len(chunks)-1], last.Text+last.Sep
+	}
+	chunk := c.chunks[0]
+	c.chunks = c.chunks[1:]
+	return chunk, nil
+}
+
+var paragraphSep = regexp.MustCompile(`[ \t\r\f\v]*\n(?:[ \t\r\f\v]*\n)+\s*`)
+
+// paragraphs splits s, which does not begin with spaces, into
+// paragraphs.
+func paragraphs(s string) []Chunk {
+	var a []Chunk
+	last := 0
+	for _, m := range paragraphSep.FindAllStringIndex(s, -1) {
+		a = append(a, Chunk{Text: s[last:m[0]], Sep: s[m[0]:m[1]]})
+		last = m[1]
+	}
+	if last < len(s) {
+		text := strings.TrimRightFunc(s[last:], unicode.IsSpace)
+		a = append(a, Chunk{Text: text, Sep: s[last+len(text):]})
+	}
+	return a
+}
+
+// split splits s at the offsets ends, after which the spaces are the
+// separators.
+func split(s string, ends []int) []Chunk {
+	var a []Chunk
+	last := 0
+	for _, i := range append(ends, len(s)) {
+		if i <= last {
+			continue
+		}
+		text := s[last:i]
+		sep := text[len(strings.TrimRightFunc(text, unicode.IsSpace)):]
+		text = text[:len(text)-len(sep)]
+		rest := s[i:]
+		more := rest[:len(rest)-len(strings.TrimLeftFunc(rest, unicode.IsSpace))]
+		a = append(a, Chunk{Text: text, Sep: sep + more})
+		last = i + len(more)
+	}
+	return a
+}
+
+// words splits a sentence into chunks of at most limit characters at
+// spaces, or anywhere if a word is too long.
+func words(s Chunk, limit int) []Chunk {
+	var a []Chunk
+	for utf8.RuneCountInString(s.Text) > limit {
+		// i is the offset of the rune beyond the limit.
+		i, n := 0, 0
+		for n < limit {
+			_, size := utf8.DecodeRuneInString(s.Text[i:])
+			i += size
+			n++
+		}
+		cut := strings.LastIndexFunc(s.Text[:i+1], unicode.IsSpace)
+		if cut <= 0 {
+			cut = i
+		}
+		head := split(s.Text, []int{cut})[0]
+		a = append(a, head)
+		s.Text = s.Text[len(head.Text)+len(head.Sep):]
+	}
+	return append(a, s)
+}
+
+// sentenceTerminals are the punctuation marks ending sentences without
+// spaces, of Chinese and Japanese.
+const sentenceTerminals = "。！？｡．"
+
+// sentenceClosers are the closing marks following the end of sentences.
+const sentenceClosers = `"')]}’”»」』）】〕〉》`
+
+// abbreviations are the words followed by periods which do not end
+// sentences, in lower case.
+var abbreviations = map[string]bool{}
+
+func init() {
+	for _, s := range strings.Fields(`mr mrs ms dr prof sr jr st mt vs etc e.g i.e cf al
+		no nos fig figs vol ch sec p pp ed eds inc ltd co corp dept est approx
+		jan feb mar apr jun jul aug sep sept oct nov dec a.m p.m u.s u.k`) {
+		abbreviations[s] = true
+	}
+}
+
+// sentenceEnds returns the offsets of the ends of the sentences in s,
+// after their punctuation marks and closing marks.
+func sentenceEnds(s string) []int {
+	var ends []int
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case strings.ContainsRune(sentenceTerminals, r):
+			i = skipMarks(s, i)
+			if i < len(s) {
+				ends = append(ends, i)
+			}
+		case r == '.' || r == '!' || r == '?':
+			start := i - size
+			i = skipMarks(s, i)
+			if i >= len(s) {
+				break
+			}
+			next, _ := utf8.DecodeRuneInString(s[i:])
+			if !unicode.IsSpace(next) {
+				break
+			}
+			if r == '.' && !periodEnds(s[:start], strings.TrimLeftFunc(s[i:], unicode.IsSpace)) {
+				break
+			}
+			ends = append(ends, i)
+		}
+	}
+	return ends
+}
+
+// skipMarks returns the offset after the punctuation marks and the
+// closing marks at s[i:].
+func skipMarks(s string, i int) int {
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if !strings.ContainsRune(".!?…"+sentenceTerminals+sentenceClosers, r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// periodEnds reports whether a period between before and after ends a
+// sentence, unless it follows an abbreviation or an initial, or is
+// followed by a lower case letter.
+func periodEnds(before, after string) bool {
+	r, _ := utf8.DecodeRuneInString(after)
+	if unicode.IsLower(r) {
+		return false
+	}
+	word := before[strings.LastIndexFunc(before, unicode.IsSpace)+1:]
+	word = strings.TrimLeft(word, `"'([{‘“«`)
+	if abbreviations[strings.ToLower(word)] {
+		return false
+	}
+	if n := utf8.RuneCountInString(word); n == 1 {
+		r, _ := utf8.DecodeRuneInString(word)
+		return !unicode.IsUpper(r)
+	}
+	return true
+}
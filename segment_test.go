@@ -0,0 +1,95 @@
+package tran
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"unicode/utf8"
+)
+
+type SplitChunksTest struct {
+	in     string
+	limit  int
+	chunks []Chunk
+}
+
+var splitchunkstests = []SplitChunksTest{
+	0: {"", 10, nil},
+	1: {" \n", 10, []Chunk{{"", " \n"}}},
+	2: {"abc\ndef\n", 10, []Chunk{{"abc\ndef", "\n"}}},
+	3: {"\nabc\n\n\ndef  \n", 10, []Chunk{{"", "\n"}, {"abc\n\n\ndef", "  \n"}}},
+	4: {"One. Two. Three.\n", 10, []Chunk{{"One. Two.", " "}, {"Three.", "\n"}}},
+	5: {"First para.\n\nSecond para is long. It has two sentences.\n", 30,
+		[]Chunk{{"First para.", "\n\n"}, {"Second para is long.", " "}, {"It has two sentences.", "\n"}}},
+	6: {"Mr. Smith met Dr. J. Doe, e.g. at 3.14 p.m. Then he left.", 30,
+		[]Chunk{{"Mr. Smith met Dr. J. Doe, e.g.", " "}, {"at 3.14 p.m. Then he left.", ""}}},
+	7: {"猫が好き。犬も好き！「本当？」うん。", 9, []Chunk{{"猫が好き。", ""}, {"犬も好き！", ""}, {"「本当？」うん。", ""}}},
+	8: {"abcdefghij", 4, []Chunk{{"abcd", ""}, {"efgh", ""}, {"ij", ""}}},
+	9: {"the quick brown fox", 10, []Chunk{{"the quick", " "}, {"brown fox", ""}}},
+	10: {"He said \"Stop!\" Then (it ended.) And so?  Yes.", 16,
+		[]Chunk{{"He said \"Stop!\"", " "}, {"Then (it ended.)", " "}, {"And so?  Yes.", ""}}},
+	11: {"Wait... what? ok", 100, []Chunk{{"Wait... what? ok", ""}}},
+	12: {"Wait... What? ok then", 8, []Chunk{{"Wait...", " "}, {"What?", " "}, {"ok then", ""}}},
+}
+
+func TestSplitChunks(t *testing.T) {
+	for i, tt := range splitchunkstests {
+		chunks := SplitChunks(tt.in, tt.limit)
+		if fmt.Sprintf("%q", chunks) != fmt.Sprintf("%q", tt.chunks) {
+			t.Errorf("#%d SplitChunks(%q, %d) =\n\thave: %q,\n\twant: %q", i, tt.in, tt.limit, chunks, tt.chunks)
+		}
+		var sb strings.Builder
+		for _, c := range chunks {
+			if n := utf8.RuneCountInString(c.Text); n > tt.limit {
+				t.Errorf("#%d chunk %q has %d characters, want: %d or less", i, c.Text, n, tt.limit)
+			}
+			sb.WriteString(c.Text + c.Sep)
+		}
+		if sb.String() != tt.in {
+			t.Errorf("#%d joined chunks = %q, want: %q", i, sb.String(), tt.in)
+		}
+	}
+}
+
+var chunkreadertests = []SplitChunksTest{
+	0: {"\n  abc\ndef\n\nghi jkl\nmno.\n", 8,
+		[]Chunk{{"", "\n  "}, {"abc\ndef", "\n\n"}, {"ghi jkl", "\n"}, {"mno.", "\n"}}},
+	1: {"One. Two.\nThree.\n\nFour", 10, []Chunk{{"One. Two.", "\n"}, {"Three.", "\n\n"}, {"Four", ""}}},
+	2: {"abcdefghij\nk", 4, []Chunk{{"abcd", ""}, {"efgh", ""}, {"ij\nk", ""}}},
+}
+
+func TestChunkReader(t *testing.T) {
+	tests := append(chunkreadertests, splitchunkstests...)
+	for i, tt := range tests {
+		cr := NewChunkReader(iotest.OneByteReader(strings.NewReader(tt.in)), tt.limit)
+		var chunks []Chunk
+		for {
+			c, err := cr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("#%d Next() have error: %v", i, err)
+			}
+			chunks = append(chunks, c)
+		}
+		if fmt.Sprintf("%q", chunks) != fmt.Sprintf("%q", tt.chunks) {
+			t.Errorf("#%d ChunkReader(%q, %d) =\n\thave: %q,\n\twant: %q", i, tt.in, tt.limit, chunks, tt.chunks)
+		}
+		if want := SplitChunks(tt.in, tt.limit); fmt.Sprintf("%q", chunks) != fmt.Sprintf("%q", want) {
+			t.Errorf("#%d ChunkReader(%q, %d) = %q, want the same as SplitChunks: %q", i, tt.in, tt.limit, chunks, want)
+		}
+	}
+}
+
+func TestChunkReader_Stream(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte("One. Two.\nThree. Four.\n"))
+	cr := NewChunkReader(pr, 10)
+	if c, err := cr.Next(); err != nil || c.Text != "One. Two." {
+		t.Errorf("Next() = (%q, %v) before the end, want: One. Two.", c, err)
+	}
+}
@@ -1,11 +1,7 @@
 package tran
 
 import (
-	"encoding/json"
-	"errors"
-	"io/ioutil"
-	"net/http"
-	"net/url"
+	"context"
 	"os"
 	"os/exec"
 	"runtime"
@@ -27,70 +23,35 @@ type TransData struct {
 	Code    int    `json:"code"`
 	Text    string `json:"text"`
 	Message string `json:"message"`
+	Source  string `json:"source"`
 }
 
 func (ep Endpoint) Translate(text, source, target string) (string, error) {
-	v := url.Values{}
-	v.Add("text", text)
-	v.Add("srouce", source)
-	v.Add("target", target)
+	return ep.TranslateContext(context.Background(), text, source, target)
+}
 
-	resp, err := http.PostForm(string(ep), v)
-	if resp != nil {
-		defer resp.Body.Close()
-	}
-	if err != nil {
-		return "", err
-	}
-	buf, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-	var td TransData
-	if err := json.Unmarshal(buf, &td); err != nil {
-		return "", err
-	}
-	if td.Code != 200 {
-		msg := td.Message
-		prefix := "exception:"
-		if strings.HasPrefix(strings.ToLower(msg), prefix) {
-			msg = string(msg[len(prefix):])
-			msg = strings.TrimSpace(msg)
-		}
-		return "", errors.New(msg)
-	}
-	return td.Text, nil
+func (ep Endpoint) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	return NewClient(ep, nil).TranslateContext(ctx, text, source, target)
+}
+
+func (ep Endpoint) TranslateResult(ctx context.Context, text, source, target string) (*Result, error) {
+	return NewClient(ep, nil).TranslateResult(ctx, text, source, target)
+}
+
+func (ep Endpoint) Languages() (ISO639List, error) {
+	return AllLangList(), nil
+}
+
+func (ep Endpoint) Detect(text string) (string, error) {
+	return NewClient(ep, nil).Detect(text)
 }
 
 func (ep Endpoint) LookupLang(s string) (code, name string, ok bool) {
-	switch {
-	case len(s) == 2:
-		if code, name, ok = LookupLangCode(s); ok {
-			return
-		}
-	case len(s) >= 3:
-		if code, name, ok = lookupLangName(s); ok {
-			return
-		}
-		if en, err := ep.Translate(s, "", "en"); err == nil {
-			if code, name, ok = lookupLangName(en); ok {
-				return
-			}
-		}
-	default:
-		// Do nothing
-	}
-	return "", "", false
+	return LookupLang(ep, s)
 }
 
 func (ep Endpoint) LangListContains(substr string) ISO639List {
-	if a := langListContains(substr); len(a) > 0 {
-		return a
-	}
-	if en, err := ep.Translate(substr, "", "en"); err == nil {
-		return langListContains(en)
-	}
-	return []*ISO639{}
+	return LangListContains(ep, substr)
 }
 
 func (ep Endpoint) CurrentLang() (code, name string) {
@@ -3,8 +3,36 @@ package tran
 import (
 	"strings"
 	"testing"
+
+	"github.com/y-bash/go-tran/trantest"
 )
 
+// newAPIServer returns a trantest.Server which supports the languages
+// of ISO639-1 and knows the translations used by the tests.
+func newAPIServer() *trantest.Server {
+	s := trantest.NewServer()
+	codes := make([]string, 0, len(iso639map))
+	for code := range iso639map {
+		codes = append(codes, code)
+	}
+	s.SetLanguages(codes...)
+	for _, tt := range []struct{ text, target, translated string }{
+		{"猫", "de", "Katze"},
+		{"猫", "en", "Cat"},
+		{"猫", "es", "Gato"},
+		{"猫", "fr", "Chat"},
+		{"猫", "it", "Gatto"},
+		{"Cat", "ja", "ネコ"},
+		{"Cat", "ko", "고양이"},
+		{"猫", "pt", "Gato"},
+		{"Cat", "zh", "猫"},
+		{"英語", "en", "English"},
+	} {
+		s.AddTranslation(tt.text, tt.target, tt.translated)
+	}
+	return s
+}
+
 type Endpoint_TranslateTest struct {
 	in     string
 	source string
@@ -27,7 +55,9 @@ var endpoint_translatetests = []Endpoint_TranslateTest{
 }
 
 func TestEndpoint_translate(t *testing.T) {
-	ep := DefaultAPI()
+	s := newAPIServer()
+	defer s.Close()
+	ep := NewAPI(s.URL)
 	for i, tt := range endpoint_translatetests {
 		out, err := ep.Translate(tt.in, tt.source, tt.target)
 		if err != nil {
@@ -72,7 +102,9 @@ var endpoint_findlangtests = []Endpoint_LookupLangTest{
 }
 
 func TestEndpoint_LookupLang(t *testing.T) {
-	ep := DefaultAPI()
+	s := newAPIServer()
+	defer s.Close()
+	ep := NewAPI(s.URL)
 	for i, tt := range endpoint_findlangtests {
 		code, name, ok := ep.LookupLang(tt.in)
 		if !ok {
@@ -105,7 +137,9 @@ var endpoint_langlistcontainstests = []Endpoint_LangListContainsTest{
 }
 
 func TestEndpoint_LangListContains(t *testing.T) {
-	ep := DefaultAPI()
+	s := newAPIServer()
+	defer s.Close()
+	ep := NewAPI(s.URL)
 	for i, tt := range endpoint_langlistcontainstests {
 		a := ep.LangListContains(tt.in)
 		if tt.in == "" {
@@ -0,0 +1,135 @@
+package tran
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrNotSupported is returned by a Translator that cannot perform
+// the requested operation, such as a backend without language detection.
+var ErrNotSupported = errors.New("not supported")
+
+// Translator is the interface implemented by translation backends.
+//
+// Translate translates text from the source language into the target
+// language. An empty source means the backend should detect it.
+// TranslateContext is like Translate but aborts when ctx is done.
+// Languages returns the languages the backend supports.
+// Detect returns the ISO639-1 code of the language of text.
+type Translator interface {
+	Translate(text, source, target string) (string, error)
+	TranslateContext(ctx context.Context, text, source, target string) (string, error)
+	Languages() (ISO639List, error)
+	Detect(text string) (string, error)
+}
+
+// Result is a translation with what the backend reports about it.
+type Result struct {
+	Text string
+
+	// Source is the code of the source language. If no source was
+	// specified, it is the language detected by the backend, or empty
+	// if the backend does not report it.
+	Source string
+
+	// Cached reports whether the translation is reused from a Cache or
+	// a Memory instead of being translated by the backend.
+	Cached bool
+
+	// Matches are the fuzzy matches of the text in a Memory, which has
+	// no exact match.
+	Matches []Match
+
+	// Pseudo reports whether Text is pseudo-localized by a Pseudo
+	// instead of translated.
+	Pseudo bool
+}
+
+// reusable reports whether r can be stored as the translation into
+// target, which is not a pseudo-localization unless target is
+// PseudoTarget.
+func (r *Result) reusable(target string) bool {
+	return !r.Pseudo || target == PseudoTarget
+}
+
+// ResultTranslator is implemented by the Translators which report the
+// detected source language along with the translation.
+type ResultTranslator interface {
+	Translator
+	TranslateResult(ctx context.Context, text, source, target string) (*Result, error)
+}
+
+// TranslateResult translates text by tr, and returns the translation
+// with the source language detected by tr if it is a ResultTranslator.
+func TranslateResult(ctx context.Context, tr Translator, text, source, target string) (*Result, error) {
+	if rt, ok := tr.(ResultTranslator); ok {
+		return rt.TranslateResult(ctx, text, source, target)
+	}
+	out, err := tr.TranslateContext(ctx, text, source, target)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Text: out, Source: source}, nil
+}
+
+// languages returns the languages supported by tr, or all the
+// languages of ISO639-1 if tr does not know them.
+func languages(tr Translator) ISO639List {
+	if a, err := tr.Languages(); err == nil && len(a) > 0 {
+		return a
+	}
+	return iso639Array
+}
+
+// LookupLang finds the language specified by a code or a (part of)
+// language name, in English or in the language itself, in the languages
+// supported by tr. If s is not found, its English translation by tr is
+// tried. PseudoTarget is always found.
+func LookupLang(tr Translator, s string) (code, name string, ok bool) {
+	if strings.ToLower(strings.TrimSpace(s)) == PseudoTarget {
+		return PseudoTarget, PseudoName, true
+	}
+	a := languages(tr)
+	switch {
+	case len(s) == 2:
+		if code, name, ok = a.lookupCode(s); ok {
+			return
+		}
+	case len(s) >= 3:
+		if code, name, ok = a.lookupCode(s); ok {
+			return
+		}
+		if code, name, ok = a.lookupName(s); ok {
+			return
+		}
+		if code, name, ok = a.lookupAutonym(s); ok {
+			return
+		}
+		if en, err := tr.Translate(s, "", "en"); err == nil {
+			if code, name, ok = a.lookupName(en); ok {
+				return
+			}
+		}
+	default:
+		// Do nothing
+	}
+	return "", "", false
+}
+
+// LangListContains returns the languages supported by tr whose code,
+// name or name in the language itself contains substr. If none is
+// found, the English translation of substr by tr is tried.
+func LangListContains(tr Translator, substr string) ISO639List {
+	a := languages(tr)
+	if b := a.contains(substr); len(b) > 0 {
+		return b
+	}
+	if b := a.containsAutonym(substr); len(b) > 0 {
+		return b
+	}
+	if en, err := tr.Translate(substr, "", "en"); err == nil {
+		return a.contains(en)
+	}
+	return []*ISO639{}
+}
@@ -0,0 +1,78 @@
+package tran
+
+import (
+	"context"
+	"testing"
+)
+
+type dictTranslator map[string]string
+
+func (d dictTranslator) Translate(text, source, target string) (string, error) {
+	return d.TranslateContext(context.Background(), text, source, target)
+}
+
+func (d dictTranslator) TranslateContext(ctx context.Context, text, source, target string) (string, error) {
+	if s, ok := d[text]; ok {
+		return s, nil
+	}
+	return text, nil
+}
+
+func (d dictTranslator) Languages() (ISO639List, error) {
+	return AllLangList(), nil
+}
+
+func (d dictTranslator) Detect(text string) (string, error) {
+	return "", ErrNotSupported
+}
+
+type LookupLangTest struct {
+	in   string
+	code string
+	name string
+	ok   bool
+}
+
+var lookuplangtests = []LookupLangTest{
+	0: {"ja", "ja", "Japanese", true},
+	1: {"jap", "ja", "Japanese", true},
+	2: {"英語", "en", "English", true},
+	3: {"zz", "", "", false},
+	4: {"z", "", "", false},
+	5: {"Deutsch", "de", "German", true},
+	6: {"日本語", "ja", "Japanese", true},
+}
+
+func TestLookupLang(t *testing.T) {
+	tr := dictTranslator{"英語": "English"}
+	for i, tt := range lookuplangtests {
+		code, name, ok := LookupLang(tr, tt.in)
+		if code != tt.code || name != tt.name || ok != tt.ok {
+			t.Errorf("#%d LookupLang(%q) = (%q, %q, %v), want: (%q, %q, %v)",
+				i, tt.in, code, name, ok, tt.code, tt.name, tt.ok)
+		}
+	}
+}
+
+type LangListContainsTest struct {
+	in string
+	a  string
+}
+
+var langlistcontainstests = []LangListContainsTest{
+	0: {"pan", "[ja:Japanese es:Spanish]"},
+	1: {"フランス語", "[fr:French]"},
+	2: {"xyz", "[]"},
+	3: {"españ", "[es:Spanish]"},
+}
+
+func TestLangListContains(t *testing.T) {
+	tr := dictTranslator{"フランス語": "French"}
+	for i, tt := range langlistcontainstests {
+		a := LangListContains(tr, tt.in)
+		if a.String() != tt.a {
+			t.Errorf("#%d LangListContains(%q) = %v, want: %v",
+				i, tt.in, a, tt.a)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Package trantest provides a stand-in for the Google Apps Script API
+// server of tran, for testing without network.
+package trantest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Response is the JSON body returned by the doPost function of the
+// API script.
+type Response struct {
+	Code    int    `json:"code"`
+	Text    string `json:"text,omitempty"`
+	Message string `json:"message,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+// Reply is a scripted reply of the Server.
+type Reply struct {
+	// Status is the HTTP status. Zero means 200.
+	Status int
+
+	// Header holds extra headers such as Retry-After.
+	Header http.Header
+
+	// Response is encoded as the body when Body is empty.
+	Response Response
+
+	// Body is sent as is, to inject a malformed response.
+	Body string
+}
+
+// Request is a request received by the Server.
+type Request struct {
+	Text   string
+	Source string
+	Target string
+	Header http.Header
+
+	// Form is the form as it is sent, which has no parameters but
+	// "text", "source" and "target", or else the request is replied
+	// with an exception.
+	Form url.Values
+}
+
+// params are the parameters of the doPost function of the API script.
+var params = map[string]bool{"text": true, "source": true, "target": true}
+
+type key struct {
+	text, target string
+}
+
+// Server is an httptest.Server which speaks the protocol of the API
+// script. It translates texts by its dictionary, and can be scripted
+// to reply failures.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	dict      map[key]string
+	detect    map[string]string
+	languages map[string]bool
+	script    []Reply
+	latency   time.Duration
+	requests  []Request
+	handler   func(req Request) *Reply
+}
+
+// NewServer starts and returns a new Server. The caller should call
+// Close when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{dict: map[key]string{}, detect: map[string]string{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddTranslation adds the translation of text into target to the
+// dictionary. Texts not in the dictionary are replied as they are.
+func (s *Server) AddTranslation(text, target, translated string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dict[key{text, target}] = translated
+}
+
+// AddDetection makes the Server reply code as the detected source
+// language of text, when the request does not specify the source.
+func (s *Server) AddDetection(text, code string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detect[text] = code
+}
+
+// SetLanguages restricts the supported language codes. Requests with
+// other codes are replied with an "Invalid argument" exception like
+// LanguageApp does. No codes means any code is supported.
+func (s *Server) SetLanguages(codes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.languages = nil
+	if len(codes) > 0 {
+		s.languages = map[string]bool{}
+		for _, c := range codes {
+			s.languages[c] = true
+		}
+	}
+}
+
+// Script queues replies which are sent, in order, instead of
+// translations for the next requests.
+func (s *Server) Script(replies ...Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = append(s.script, replies...)
+}
+
+// HandleFunc sets f to decide the reply to each request. If f returns
+// nil, the request is handled as usual.
+func (s *Server) HandleFunc(f func(req Request) *Reply) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handler = f
+}
+
+// SetLatency sets the delay before each reply.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Reset clears the requests, the script and the handler.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+	s.script = nil
+	s.handler = nil
+}
+
+func (s *Server) reply(req Request) Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	if s.handler != nil {
+		if r := s.handler(req); r != nil {
+			return *r
+		}
+	}
+	for k := range req.Form {
+		if !params[k] {
+			return Reply{Response: Response{
+				Code: 400, Message: "Exception: Unknown parameter: " + k}}
+		}
+	}
+	if len(s.script) > 0 {
+		r := s.script[0]
+		s.script = s.script[1:]
+		return r
+	}
+	if s.languages != nil {
+		if !s.languages[req.Target] {
+			return Reply{Response: Response{
+				Code: 400, Message: "Exception: Invalid argument: target"}}
+		}
+		if req.Source != "" && !s.languages[req.Source] {
+			return Reply{Response: Response{
+				Code: 400, Message: "Exception: Invalid argument: source"}}
+		}
+	}
+	text := req.Text
+	if t, ok := s.dict[key{req.Text, req.Target}]; ok {
+		text = t
+	}
+	res := Response{Code: 200, Text: text}
+	if req.Source == "" {
+		res.Source = s.detect[req.Text]
+	}
+	return Reply{Response: res}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	req := Request{
+		Text:   r.PostForm.Get("text"),
+		Source: r.PostForm.Get("source"),
+		Target: r.PostForm.Get("target"),
+		Header: r.Header.Clone(),
+		Form:   r.PostForm,
+	}
+	rep := s.reply(req)
+
+	s.mu.Lock()
+	latency := s.latency
+	s.mu.Unlock()
+	if latency > 0 {
+		t := time.NewTimer(latency)
+		defer t.Stop()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-t.C:
+		}
+	}
+
+	for k, vs := range rep.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if rep.Status != 0 {
+		w.WriteHeader(rep.Status)
+	}
+	if rep.Body != "" {
+		w.Write([]byte(rep.Body))
+		return
+	}
+	json.NewEncoder(w).Encode(rep.Response)
+}
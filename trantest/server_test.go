@@ -0,0 +1,99 @@
+package trantest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/y-bash/go-tran"
+	"github.com/y-bash/go-tran/trantest"
+)
+
+type ServerTest struct {
+	script []trantest.Reply
+	text   string
+	target string
+	out    string
+	err    string
+}
+
+var servertests = []ServerTest{
+	0: {nil, "猫", "en", "Cat", ""},
+	1: {nil, "犬", "en", "犬", ""},
+	2: {nil, "猫", "xx", "", "Invalid argument: target"},
+	3: {[]trantest.Reply{{Response: trantest.Response{Code: 200, Text: "Neko"}}},
+		"猫", "en", "Neko", ""},
+	4: {[]trantest.Reply{{Response: trantest.Response{Code: 500, Message: "Exception: Boom"}}},
+		"猫", "en", "", "Boom"},
+	5: {[]trantest.Reply{{Status: http.StatusBadGateway}},
+		"猫", "en", "", "502 Bad Gateway"},
+}
+
+func TestServer(t *testing.T) {
+	s := trantest.NewServer()
+	defer s.Close()
+	s.SetLanguages("en", "ja")
+	s.AddTranslation("猫", "en", "Cat")
+	ep := tran.NewAPI(s.URL)
+	for i, tt := range servertests {
+		s.Reset()
+		s.Script(tt.script...)
+		out, err := ep.Translate(tt.text, "", tt.target)
+		if err != nil {
+			if err.Error() != tt.err {
+				t.Errorf("#%d have error: %s, want error: %q", i, err, tt.err)
+			}
+			continue
+		}
+		if tt.err != "" {
+			t.Errorf("#%d have error: nil, want error: %q", i, tt.err)
+			continue
+		}
+		if out != tt.out {
+			t.Errorf("#%d Translate(%q, %q) = %q, want: %q",
+				i, tt.text, tt.target, out, tt.out)
+		}
+		reqs := s.Requests()
+		if len(reqs) != 1 || reqs[0].Text != tt.text || reqs[0].Target != tt.target {
+			t.Errorf("#%d Requests() = %+v, want: [{%q %q}]",
+				i, reqs, tt.text, tt.target)
+		}
+	}
+}
+
+func TestServer_HandleFunc(t *testing.T) {
+	s := trantest.NewServer()
+	defer s.Close()
+	s.HandleFunc(func(req trantest.Request) *trantest.Reply {
+		if req.Text == "quota" {
+			return &trantest.Reply{Response: trantest.Response{
+				Code: 400, Message: "Exception: Service invoked too many times for one day"}}
+		}
+		return nil
+	})
+	ep := tran.NewAPI(s.URL)
+	if _, err := ep.Translate("quota", "", "en"); !errors.Is(err, tran.ErrQuotaExceeded) {
+		t.Errorf("have error: %v, want error: %v", err, tran.ErrQuotaExceeded)
+	}
+	if out, err := ep.Translate("ok", "", "en"); err != nil || out != "ok" {
+		t.Errorf("Translate() = (%q, %v), want: (%q, nil)", out, err, "ok")
+	}
+}
+
+func TestServer_SetLatency(t *testing.T) {
+	s := trantest.NewServer()
+	defer s.Close()
+	s.SetLatency(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := tran.NewAPI(s.URL).TranslateContext(ctx, "猫", "", "en")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("have error: %v, want error: %v", err, context.DeadlineExceeded)
+	}
+	if d := time.Since(start); d >= time.Second {
+		t.Errorf("TranslateContext() took %v, want: < 1s", d)
+	}
+}